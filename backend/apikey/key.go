@@ -0,0 +1,53 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+)
+
+type Key struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	SecretHash string `json:"secret_hash"`
+
+	// Payout is the amount of tokens transferred per request made with the
+	// key, in the same format as the faucet's payout (empty means the
+	// faucet's default payout).
+	Payout string `json:"payout,omitempty"`
+
+	// Quota is the number of requests the key is allowed to make during
+	// QuotaWindow (zero means unlimited).
+	Quota       int64         `json:"quota"`
+	QuotaWindow time.Duration `json:"quota_window"`
+
+	// AllowedAddresses restricts the recipients the key can fund (empty means
+	// any address).
+	AllowedAddresses []string `json:"allowed_addresses,omitempty"`
+
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type Usage struct {
+	Timestamp time.Time `json:"timestamp"`
+	Address   string    `json:"address"`
+	IP        string    `json:"ip,omitempty"`
+	TxHash    string    `json:"tx_hash,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+func (k *Key) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+func (k *Key) IsAddressAllowed(address string) bool {
+	if len(k.AllowedAddresses) == 0 {
+		return true
+	}
+	for _, allowed := range k.AllowedAddresses {
+		if strings.EqualFold(allowed, address) {
+			return true
+		}
+	}
+	return false
+}
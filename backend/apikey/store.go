@@ -0,0 +1,235 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TokenPrefix distinguishes api keys from jwt tokens in the authorisation
+	// header.
+	TokenPrefix = "faucet_"
+
+	maxUsageRecords = 10000
+)
+
+var (
+	ErrKeyMalformed = errors.New("api key is malformed")
+	ErrKeyNotFound  = errors.New("api key not found")
+	ErrKeyRevoked   = errors.New("api key is revoked")
+	ErrKeyMismatch  = errors.New("api key does not match")
+)
+
+type Store struct {
+	backoffParams *backoff.Parameters
+	prefix        string
+	redis         *redis.Client
+}
+
+func New(cfg *config.Config, _redis *redis.Client) *Store {
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	return &Store{
+		backoffParams: backoffParams,
+		prefix:        redisutils.Prefix(&cfg.Redis),
+		redis:         _redis,
+	}
+}
+
+func IsToken(token string) bool {
+	return strings.HasPrefix(token, TokenPrefix)
+}
+
+// Create stores the key and returns the token that must be handed over to the
+// key's user.  Only the hash of the token's secret is persisted.
+func (s *Store) Create(ctx context.Context, key *Key) (string, error) {
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomString(32, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return "", err
+	}
+
+	key.ID = id
+	key.SecretHash = hashSecret(secret)
+	key.CreatedAt = time.Now().UTC()
+	key.RevokedAt = nil
+
+	if err := s.save(ctx, key); err != nil {
+		return "", err
+	}
+	err = backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) error {
+		return s.redis.SAdd(ctx, s.prefix+"apikeys", id).Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return TokenPrefix + id + "_" + secret, nil
+}
+
+func (s *Store) Get(ctx context.Context, id string) (*Key, error) {
+	var res string
+	err := backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) (_err error) {
+		res, _err = s.redis.Get(ctx, s.keyOf(id)).Result()
+		return
+	})
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{}
+	if err := json.Unmarshal([]byte(res), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *Store) List(ctx context.Context) ([]*Key, error) {
+	var ids []string
+	err := backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) (_err error) {
+		ids, _err = s.redis.SMembers(ctx, s.prefix+"apikeys").Result()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+
+	keys := make([]*Key, 0, len(ids))
+	for _, id := range ids {
+		key, err := s.Get(ctx, id)
+		if errors.Is(err, ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if key.IsRevoked() {
+		return nil
+	}
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	return s.save(ctx, key)
+}
+
+// Authenticate resolves the token into a valid (not revoked) key.
+func (s *Store) Authenticate(ctx context.Context, token string) (*Key, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, TokenPrefix), "_")
+	if !IsToken(token) || !ok || id == "" || secret == "" {
+		return nil, ErrKeyMalformed
+	}
+
+	key, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrKeyMismatch
+	}
+	if key.IsRevoked() {
+		return nil, ErrKeyRevoked
+	}
+	return key, nil
+}
+
+func (s *Store) RecordUsage(ctx context.Context, id string, usage *Usage) error {
+	b, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	key := s.usageKeyOf(id)
+	return backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) (_err error) {
+		_, _err = s.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.LPush(ctx, key, b)
+			pipe.LTrim(ctx, key, 0, maxUsageRecords-1)
+			return nil
+		})
+		return
+	})
+}
+
+// Usage returns up to `limit` most recent usage records of the key.
+func (s *Store) Usage(ctx context.Context, id string, limit int64) ([]*Usage, error) {
+	var res []string
+	err := backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) (_err error) {
+		res, _err = s.redis.LRange(ctx, s.usageKeyOf(id), 0, limit-1).Result()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make([]*Usage, 0, len(res))
+	for _, r := range res {
+		u := &Usage{}
+		if err := json.Unmarshal([]byte(r), u); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, nil
+}
+
+func (s *Store) save(ctx context.Context, key *Key) error {
+	b, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) error {
+		return s.redis.Set(ctx, s.keyOf(key.ID), b, 0).Err()
+	})
+}
+
+func (s *Store) keyOf(id string) string {
+	return s.prefix + "apikey:" + id
+}
+
+func (s *Store) usageKeyOf(id string) string {
+	return s.prefix + "apikey-usage:" + id
+}
+
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
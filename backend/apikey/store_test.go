@@ -0,0 +1,123 @@
+package apikey_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) (*apikey.Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	s := apikey.New(&config.Config{
+		Redis: config.Redis{Namespace: "faucet", Timeout: time.Second},
+	}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	return s, mr
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+
+	key := &apikey.Key{
+		Name:             "ci",
+		Payout:           "0.5",
+		Quota:            10,
+		QuotaWindow:      time.Hour,
+		AllowedAddresses: []string{"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"},
+	}
+	token, err := s.Create(ctx, key)
+	require.NoError(t, err)
+	require.True(t, apikey.IsToken(token))
+
+	// only the hash of the secret is stored
+	_, secret, _ := strings.Cut(strings.TrimPrefix(token, apikey.TokenPrefix+key.ID), "_")
+	hash := sha256.Sum256([]byte(secret))
+	assert.Equal(t, hex.EncodeToString(hash[:]), key.SecretHash)
+	stored, err := mr.Get("faucet:apikey:" + key.ID)
+	require.NoError(t, err)
+	assert.NotContains(t, stored, secret)
+
+	authenticated, err := s.Authenticate(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, authenticated.ID)
+	assert.Equal(t, "0.5", authenticated.Payout)
+	assert.Equal(t, int64(10), authenticated.Quota)
+	assert.Equal(t, time.Hour, authenticated.QuotaWindow)
+	assert.True(t, authenticated.IsAddressAllowed("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"))
+	assert.False(t, authenticated.IsAddressAllowed("0x0000000000000000000000000000000000000001"))
+
+	for token, expected := range map[string]error{
+		token + "x":                           apikey.ErrKeyMismatch,
+		apikey.TokenPrefix + "unknown_secret": apikey.ErrKeyNotFound,
+		apikey.TokenPrefix + key.ID:           apikey.ErrKeyMalformed,
+		"eyJhbGciOiJIUzI1NiJ9":                apikey.ErrKeyMalformed,
+	} {
+		_, err := s.Authenticate(ctx, token)
+		assert.ErrorIs(t, err, expected, token)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+
+	revoked := &apikey.Key{Name: "revoked"}
+	token, err := s.Create(ctx, revoked)
+	require.NoError(t, err)
+	active := &apikey.Key{Name: "active"}
+	_, err = s.Create(ctx, active)
+	require.NoError(t, err)
+
+	require.NoError(t, s.Revoke(ctx, revoked.ID))
+	require.NoError(t, s.Revoke(ctx, revoked.ID), "revoking again is a no-op")
+	assert.ErrorIs(t, s.Revoke(ctx, "unknown"), apikey.ErrKeyNotFound)
+
+	_, err = s.Authenticate(ctx, token)
+	assert.ErrorIs(t, err, apikey.ErrKeyRevoked)
+
+	// the revoked keys are still listed
+	keys, err := s.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	for _, key := range keys {
+		assert.Equal(t, key.ID == revoked.ID, key.IsRevoked(), key.Name)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+
+	key := &apikey.Key{Name: "ci"}
+	_, err := s.Create(ctx, key)
+	require.NoError(t, err)
+
+	for _, txHash := range []string{"0x01", "0x02", "0x03"} {
+		require.NoError(t, s.RecordUsage(ctx, key.ID, &apikey.Usage{
+			Timestamp: time.Now().UTC(),
+			Address:   "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+			TxHash:    txHash,
+		}))
+	}
+
+	// the most recent first
+	usage, err := s.Usage(ctx, key.ID, 2)
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, "0x03", usage[0].TxHash)
+	assert.Equal(t, "0x02", usage[1].TxHash)
+
+	usage, err = s.Usage(ctx, "unknown", 10)
+	require.NoError(t, err)
+	assert.Empty(t, usage)
+}
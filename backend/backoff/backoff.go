@@ -1,14 +1,21 @@
 package backoff
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"runtime"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -38,6 +45,7 @@ func Backoff(
 
 	errs := make([]error, 0)
 	attempt := 1
+	operation := caller()
 	timeout := params.BaseTimeout
 
 	for time.Now().Before(deadline) {
@@ -55,8 +63,13 @@ func Backoff(
 		_ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
+		_ctx, span := tracing.Start(_ctx, operation, trace.WithAttributes(
+			attribute.Int("backoff.attempt", attempt),
+			attribute.String("backoff.timeout", timeout.String()),
+		))
 		err := payload(_ctx)
 		ctxErr := _ctx.Err()
+		tracing.End(span, cmp.Or(err, ctxErr))
 
 		switch {
 		case err == nil && ctxErr == nil:
@@ -90,6 +103,8 @@ func Backoff(
 
 		time.Sleep(timeout - time.Since(start))
 
+		metrics.RecordBackoffRetry(operation)
+
 		timeout = time.Duration(params.Multiplier * float64(timeout))
 		attempt++
 	}
@@ -100,3 +115,14 @@ func Backoff(
 		errors.Join(errs...),
 	)
 }
+
+// caller returns the name of the function that invoked the backoff (e.g.
+// `txbuilder.(*TxBuilder).SendFunds`), which identifies the operation in the
+// metrics and the traces.
+func caller() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	return strings.TrimPrefix(runtime.FuncForPC(pc).Name(), "github.com/flashbots/eth-faucet/")
+}
@@ -0,0 +1,136 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+)
+
+const (
+	ProviderHCaptcha  = "hcaptcha"
+	ProviderReCaptcha = "recaptcha"
+	ProviderTurnstile = "turnstile"
+)
+
+var (
+	ErrCaptchaFailed              = errors.New("captcha verification failed")
+	ErrCaptchaScoreTooLow         = errors.New("captcha score is too low")
+	ErrCaptchaTokenMissing        = errors.New("captcha token is missing")
+	ErrProviderUnknown            = errors.New("unknown captcha provider")
+	ErrSiteverifyUnexpectedStatus = errors.New("unexpected siteverify response status")
+)
+
+var defaultVerifyURLs = map[string]string{
+	ProviderHCaptcha:  "https://api.hcaptcha.com/siteverify",
+	ProviderReCaptcha: "https://www.google.com/recaptcha/api/siteverify",
+	ProviderTurnstile: "https://challenges.cloudflare.com/turnstile/v0/siteverify",
+}
+
+// Verifier checks the captcha token that the client has obtained from the
+// captcha provider's widget.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// New returns the verifier for the configured provider, or nil if captcha
+// verification is not configured.
+func New(cfg *config.Captcha) (Verifier, error) {
+	provider := strings.ToLower(cfg.Provider)
+	if provider == "" {
+		return nil, nil
+	}
+
+	verifyURL, known := defaultVerifyURLs[provider]
+	if !known {
+		return nil, fmt.Errorf("%w: %s", ErrProviderUnknown, cfg.Provider)
+	}
+	if cfg.VerifyURL != "" {
+		verifyURL = cfg.VerifyURL
+	}
+
+	return &siteverify{
+		backoffParams: &backoff.Parameters{
+			BaseTimeout: cfg.Timeout,
+		},
+		client:    &http.Client{},
+		minScore:  cfg.MinScore,
+		provider:  provider,
+		secret:    cfg.Secret,
+		verifyURL: verifyURL,
+	}, nil
+}
+
+// siteverify implements the verification protocol that is shared by hcaptcha,
+// cloudflare turnstile and google recaptcha.
+type siteverify struct {
+	backoffParams *backoff.Parameters
+	client        *http.Client
+	minScore      float64
+	provider      string
+	secret        string
+	verifyURL     string
+}
+
+type siteverifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+	Score      *float64 `json:"score"`
+}
+
+func (v *siteverify) Verify(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrCaptchaTokenMissing
+	}
+
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	res := &siteverifyResponse{}
+	err := backoff.Backoff(ctx, v.backoffParams, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := v.client.Do(req)
+		if err != nil {
+			return backoff.Retryable(err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("%w: %d", ErrSiteverifyUnexpectedStatus, resp.StatusCode)
+			if resp.StatusCode >= http.StatusInternalServerError {
+				return backoff.Retryable(err)
+			}
+			return err
+		}
+		return json.NewDecoder(resp.Body).Decode(res)
+	})
+	if err != nil {
+		return err
+	}
+
+	if !res.Success {
+		return fmt.Errorf("%w: %s", ErrCaptchaFailed, strings.Join(res.ErrorCodes, ", "))
+	}
+	// only recaptcha v3 reports a "humanness" score (hcaptcha's enterprise
+	// score has inverse meaning, and turnstile has none)
+	if v.provider == ProviderReCaptcha && res.Score != nil && *res.Score < v.minScore {
+		return fmt.Errorf("%w: %.2f < %.2f", ErrCaptchaScoreTooLow, *res.Score, v.minScore)
+	}
+	return nil
+}
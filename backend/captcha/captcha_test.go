@@ -0,0 +1,74 @@
+package captcha_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/captcha"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeSiteverify(t *testing.T, score float64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "10.0.0.1", r.PostForm.Get("remoteip"))
+
+		res := map[string]any{"success": r.PostForm.Get("response") == "valid"}
+		if r.PostForm.Get("response") != "valid" {
+			res["error-codes"] = []string{"invalid-input-response"}
+		}
+		if score >= 0 {
+			res["score"] = score
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(res))
+	}))
+}
+
+func TestVerify(t *testing.T) {
+	srv := fakeSiteverify(t, -1)
+	defer srv.Close()
+
+	v, err := captcha.New(&config.Captcha{
+		Provider:  captcha.ProviderTurnstile,
+		Secret:    "secret",
+		Timeout:   time.Second,
+		VerifyURL: srv.URL,
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, v.Verify(context.Background(), "valid", "10.0.0.1"))
+	assert.ErrorIs(t, v.Verify(context.Background(), "invalid", "10.0.0.1"), captcha.ErrCaptchaFailed)
+	assert.ErrorIs(t, v.Verify(context.Background(), "", "10.0.0.1"), captcha.ErrCaptchaTokenMissing)
+}
+
+func TestVerifyScore(t *testing.T) {
+	srv := fakeSiteverify(t, 0.3)
+	defer srv.Close()
+
+	v, err := captcha.New(&config.Captcha{
+		MinScore:  0.5,
+		Provider:  captcha.ProviderReCaptcha,
+		Secret:    "secret",
+		Timeout:   time.Second,
+		VerifyURL: srv.URL,
+	})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, v.Verify(context.Background(), "valid", "10.0.0.1"), captcha.ErrCaptchaScoreTooLow)
+}
+
+func TestNew(t *testing.T) {
+	v, err := captcha.New(&config.Captcha{})
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+
+	_, err = captcha.New(&config.Captcha{Provider: "unknown"})
+	assert.ErrorIs(t, err, captcha.ErrProviderUnknown)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/flashbots/eth-faucet/units"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	ErrAPIKeyIDMissing          = errors.New("api key id is missing")
+	ErrAPIKeyInvalidAddress     = errors.New("invalid allowed address")
+	ErrAPIKeyInvalidPayout      = errors.New("invalid payout")
+	ErrAPIKeyInvalidQuotaWindow = errors.New("quota window must be positive")
+	ErrAPIKeyNameMissing        = errors.New("api key name is missing")
+)
+
+func CommandAPIKey(cfg *config.Config) *cli.Command {
+	key := &apikey.Key{}
+	allowedAddresses := &cli.StringSlice{}
+	usageLimit := int64(0)
+
+	createFlags := []cli.Flag{
+		&cli.StringSliceFlag{
+			Destination: allowedAddresses,
+			Name:        "allowed-address",
+			Usage:       "recipient `address` the key is allowed to fund, can be repeated (any address if omitted)",
+		},
+
+		&cli.StringFlag{
+			Destination: &key.Name,
+			Name:        "name",
+			Required:    true,
+			Usage:       "human-readable `name` of the key (e.g. the ci pipeline it is issued for)",
+		},
+
+		&cli.StringFlag{
+			Destination: &key.Payout,
+			Name:        "payout",
+			Usage:       "`amount` of tokens to transfer per request (e.g. 0.05 or 50000000 gwei; the faucet's payout if omitted)",
+		},
+
+		&cli.Int64Flag{
+			Destination: &key.Quota,
+			Name:        "quota",
+			Usage:       "`count` of requests allowed per quota window (0 means unlimited)",
+		},
+
+		&cli.DurationFlag{
+			Destination: &key.QuotaWindow,
+			Name:        "quota-window",
+			Usage:       "`duration` of the quota window",
+			Value:       24 * time.Hour,
+		},
+	}
+
+	usageFlags := []cli.Flag{
+		&cli.Int64Flag{
+			Destination: &usageLimit,
+			Name:        "limit",
+			Usage:       "max `count` of most recent usage records to show",
+			Value:       100,
+		},
+	}
+
+	return &cli.Command{
+		Name:  "apikey",
+		Usage: "manage api keys for automated clients",
+		Flags: flagsRedis(cfg),
+
+		Before: func(clictx *cli.Context) error {
+			_, err := loadConfigFile(clictx, cfg)
+			return err
+		},
+
+		Subcommands: []*cli.Command{
+			{
+				Name:  "create",
+				Usage: "create new api key",
+				Flags: createFlags,
+
+				Action: func(clictx *cli.Context) error {
+					if strings.TrimSpace(key.Name) == "" {
+						return ErrAPIKeyNameMissing
+					}
+					if key.QuotaWindow <= 0 {
+						return ErrAPIKeyInvalidQuotaWindow
+					}
+					if key.Payout != "" {
+						if err := units.Validate(key.Payout); err != nil {
+							return fmt.Errorf("%w: %w", ErrAPIKeyInvalidPayout, err)
+						}
+					}
+					for _, address := range allowedAddresses.Value() {
+						if !common.IsHexAddress(address) {
+							return fmt.Errorf("%w: %s", ErrAPIKeyInvalidAddress, address)
+						}
+						key.AllowedAddresses = append(key.AllowedAddresses, common.HexToAddress(address).Hex())
+					}
+
+					store, err := apikeyStore(cfg)
+					if err != nil {
+						return err
+					}
+					token, err := store.Create(clictx.Context, key)
+					if err != nil {
+						return err
+					}
+
+					fmt.Printf("Created api key %s (%s).\n", key.ID, key.Name)
+					fmt.Printf("Token (it will not be shown again):\n\n%s\n", token)
+					return nil
+				},
+			},
+
+			{
+				Name:  "list",
+				Usage: "list api keys",
+
+				Action: func(clictx *cli.Context) error {
+					store, err := apikeyStore(cfg)
+					if err != nil {
+						return err
+					}
+					keys, err := store.List(clictx.Context)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(w, "ID\tNAME\tPAYOUT\tQUOTA\tALLOWED ADDRESSES\tCREATED\tREVOKED")
+					for _, k := range keys {
+						payout := "default"
+						if k.Payout != "" {
+							payout = k.Payout
+						}
+						quota := "unlimited"
+						if k.Quota > 0 {
+							quota = fmt.Sprintf("%d/%s", k.Quota, k.QuotaWindow)
+						}
+						allowed := "any"
+						if len(k.AllowedAddresses) > 0 {
+							allowed = strings.Join(k.AllowedAddresses, ",")
+						}
+						revoked := "-"
+						if k.IsRevoked() {
+							revoked = k.RevokedAt.Format(time.RFC3339)
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+							k.ID, k.Name, payout, quota, allowed, k.CreatedAt.Format(time.RFC3339), revoked,
+						)
+					}
+					return w.Flush()
+				},
+			},
+
+			{
+				Name:      "revoke",
+				Usage:     "revoke api key",
+				ArgsUsage: "<id>",
+
+				Action: func(clictx *cli.Context) error {
+					id := clictx.Args().First()
+					if id == "" {
+						return ErrAPIKeyIDMissing
+					}
+
+					store, err := apikeyStore(cfg)
+					if err != nil {
+						return err
+					}
+					if err := store.Revoke(clictx.Context, id); err != nil {
+						return err
+					}
+
+					fmt.Printf("Revoked api key %s.\n", id)
+					return nil
+				},
+			},
+
+			{
+				Name:      "usage",
+				Usage:     "show usage records of api key",
+				ArgsUsage: "<id>",
+				Flags:     usageFlags,
+
+				Action: func(clictx *cli.Context) error {
+					id := clictx.Args().First()
+					if id == "" {
+						return ErrAPIKeyIDMissing
+					}
+
+					store, err := apikeyStore(cfg)
+					if err != nil {
+						return err
+					}
+					if _, err := store.Get(clictx.Context, id); err != nil {
+						return err
+					}
+					usage, err := store.Usage(clictx.Context, id, usageLimit)
+					if err != nil {
+						return err
+					}
+
+					w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(w, "TIMESTAMP\tADDRESS\tIP\tTX HASH\tERROR")
+					for _, u := range usage {
+						txHash := u.TxHash
+						if txHash == "" {
+							txHash = "-"
+						}
+						errMsg := u.Error
+						if errMsg == "" {
+							errMsg = "-"
+						}
+						fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+							u.Timestamp.Format(time.RFC3339), u.Address, u.IP, txHash, errMsg,
+						)
+					}
+					return w.Flush()
+				},
+			},
+		},
+	}
+}
+
+func apikeyStore(cfg *config.Config) (*apikey.Store, error) {
+	_redis, err := redisutils.Connect(&cfg.Redis, &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return apikey.New(cfg, _redis), nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrConfigFileMissing = errors.New("config file is not set")
+	ErrConfigProbeFailed = errors.New("config probe failed")
+)
+
+const probeTimeout = 10 * time.Second
+
+func CommandConfig(cfg *config.Config) *cli.Command {
+	probe := false
+
+	flags, before, _ := flagsServe(cfg)
+
+	checkFlags := append([]cli.Flag{
+		&cli.BoolFlag{
+			Destination: &probe,
+			Name:        "probe",
+			Usage:       "also check that redis and the rpc endpoint are reachable",
+		},
+	}, flags...)
+
+	return &cli.Command{
+		Name:  "config",
+		Usage: "inspect the server configuration",
+
+		Subcommands: []*cli.Command{
+			{
+				Name:   "check",
+				Usage:  "validate the configuration and print it with secrets redacted",
+				Flags:  checkFlags,
+				Before: before,
+
+				Action: func(_ *cli.Context) error {
+					if err := cfg.Validate(); err != nil {
+						return err
+					}
+
+					out, err := yaml.Marshal(cfg.Redacted())
+					if err != nil {
+						return err
+					}
+					fmt.Fprint(os.Stdout, string(out))
+
+					if !probe {
+						return nil
+					}
+					return probeConfig(cfg)
+				},
+			},
+		},
+	}
+}
+
+func probeConfig(cfg *config.Config) error {
+	errs := make([]error, 0)
+
+	_redis, err := redisutils.Connect(&cfg.Redis, &backoff.Parameters{
+		BaseTimeout:  cfg.Redis.Timeout,
+		TotalTimeout: probeTimeout,
+	})
+	if err != nil {
+		errs = append(errs, fmt.Errorf("redis: %w", err))
+	} else {
+		_redis.Close()
+		fmt.Fprintln(os.Stderr, "redis: ok")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	// the endpoints are named by their index (their urls often hold the keys)
+	for i, endpoint := range cfg.RPC.Endpoints {
+		name := fmt.Sprintf("rpc.endpoints[%d]", i)
+		if err := probeRPC(ctx, name, endpoint); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w:\n%w", ErrConfigProbeFailed, errors.Join(errs...))
+	}
+	return nil
+}
+
+func probeRPC(ctx context.Context, name, endpoint string) error {
+	client, err := ethclient.DialContext(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		return err
+	}
+	block, err := client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s: ok (chain id %s, block %d)\n", name, chainID, block)
+	return nil
+}
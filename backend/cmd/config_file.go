@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+)
+
+// loadConfigFile applies the yaml config file (if one is given) to the config
+// so that the precedence is: defaults < file < env < flags.  The overrides are
+// applied last (e.g. to copy the values of slice flags).
+//
+// The returned function reads the file again with the same precedence into a
+// new config (it is nil when there is no config file).
+func loadConfigFile(
+	clictx *cli.Context,
+	cfg *config.Config,
+	overrides ...func(cfg *config.Config),
+) (func() (*config.Config, error), error) {
+	path := clictx.String("config")
+	if path == "" {
+		for _, o := range overrides {
+			o(cfg)
+		}
+		return nil, nil
+	}
+
+	// remember the values that came from env or command line...
+	flagOverrides := make([]func(*config.Config), 0)
+	for _, ctx := range clictx.Lineage() {
+		if ctx.Command == nil {
+			continue
+		}
+		for _, flag := range ctx.Command.Flags {
+			if !ctx.IsSet(flag.Names()[0]) {
+				continue
+			}
+			if o := snapshotFlagDestination(cfg, flag); o != nil {
+				flagOverrides = append(flagOverrides, o)
+			}
+		}
+	}
+	overrides = append(flagOverrides, overrides...)
+
+	// ...and put them back on top of the ones from the file
+	base := *cfg
+	load := func() (*config.Config, error) {
+		next := base
+		if err := config.LoadFile(path, &next); err != nil {
+			return nil, err
+		}
+		for _, o := range overrides {
+			o(&next)
+		}
+		return &next, nil
+	}
+
+	next, err := load()
+	if err != nil {
+		return nil, err
+	}
+	logCfg := cfg.Log
+	*cfg = *next
+
+	if cfg.Log != logCfg {
+		l, err := logutils.NewLogger(&cfg.Log)
+		if err != nil {
+			return nil, err
+		}
+		zap.ReplaceGlobals(l)
+	}
+
+	zap.L().Info("Loaded config file", zap.String("config_file", path))
+	return load, nil
+}
+
+// snapshotFlagDestination returns the function that sets the current value of
+// the flag's destination into the same field of another config (or nil if the
+// flag has no destination within the config).
+func snapshotFlagDestination(cfg *config.Config, flag cli.Flag) func(*config.Config) {
+	v := reflect.ValueOf(flag)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	dst := v.Elem().FieldByName("Destination")
+	if !dst.IsValid() || dst.Kind() != reflect.Pointer || dst.IsNil() {
+		return nil
+	}
+
+	switch dst.Interface().(type) {
+	case *cli.StringSlice, *cli.IntSlice, *cli.Int64Slice, *cli.Float64Slice, *cli.UintSlice, *cli.Uint64Slice:
+		// slice flags are copied into the config by the commands themselves
+		return nil
+	}
+
+	index := fieldIndex(reflect.ValueOf(cfg).Elem(), dst)
+	if index == nil {
+		return nil
+	}
+
+	value := reflect.New(dst.Elem().Type()).Elem()
+	value.Set(dst.Elem())
+	return func(cfg *config.Config) {
+		reflect.ValueOf(cfg).Elem().FieldByIndex(index).Set(value)
+	}
+}
+
+// fieldIndex finds the index of the struct's (nested) field that the pointer
+// points to.
+func fieldIndex(v reflect.Value, ptr reflect.Value) []int {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if field.Addr().Pointer() == ptr.Pointer() && field.Type() == ptr.Elem().Type() {
+			return []int{i}
+		}
+		if field.Kind() == reflect.Struct {
+			if index := fieldIndex(field, ptr); index != nil {
+				return append([]int{i}, index...)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	ErrHealthcheckFailed = errors.New("healthcheck failed")
+)
+
+func CommandHealthcheck(cfg *config.Config) *cli.Command {
+	liveness := false
+	timeout := time.Duration(0)
+
+	flags, before, _ := flagsServe(cfg)
+
+	healthcheckFlags := append([]cli.Flag{
+		&cli.BoolFlag{
+			Destination: &liveness,
+			Name:        "liveness",
+			Usage:       "only check that the server is alive (/healthz instead of /readyz)",
+		},
+
+		&cli.DurationFlag{
+			Destination: &timeout,
+			Name:        "timeout",
+			Usage:       "`timeout` for the check",
+			Value:       10 * time.Second,
+		},
+	}, flags...)
+
+	return &cli.Command{
+		Name:   "healthcheck",
+		Usage:  "check that the server running locally is ready to pay out (e.g. for docker's HEALTHCHECK)",
+		Flags:  healthcheckFlags,
+		Before: before,
+
+		Action: func(_ *cli.Context) error {
+			path := "/readyz"
+			if liveness {
+				path = "/healthz"
+			}
+			return healthcheck(localURL(&cfg.Server)+path, timeout)
+		},
+	}
+}
+
+func healthcheck(url string, timeout time.Duration) error {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// the certificate is hardly issued for the loopback address
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+		},
+	}
+
+	res, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrHealthcheckFailed, err)
+	}
+	defer res.Body.Close()
+
+	if _, err := io.Copy(os.Stdout, res.Body); err != nil {
+		return fmt.Errorf("%w: %w", ErrHealthcheckFailed, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", ErrHealthcheckFailed, res.Status)
+	}
+	return nil
+}
+
+// localURL returns the url at which the server is reachable from the same
+// host.
+func localURL(cfg *config.Server) string {
+	scheme := "http://"
+	if cfg.TLSEnabled() {
+		scheme = "https://"
+	}
+	host, port, err := net.SplitHostPort(cfg.ListenAddress)
+	if err != nil {
+		return scheme + cfg.ListenAddress
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	return scheme + net.JoinHostPort(host, port)
+}
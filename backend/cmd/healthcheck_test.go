@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthcheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_, _ = w.Write([]byte("{}\n"))
+	}))
+	defer srv.Close()
+
+	assert.NoError(t, healthcheck(srv.URL+"/readyz", time.Second))
+	assert.ErrorIs(t, healthcheck(srv.URL+"/healthz", time.Second), ErrHealthcheckFailed)
+
+	srv.Close()
+	assert.ErrorIs(t, healthcheck(srv.URL+"/readyz", time.Second), ErrHealthcheckFailed)
+}
+
+func TestLocalURL(t *testing.T) {
+	for cfg, expected := range map[config.Server]string{
+		{ListenAddress: "0.0.0.0:8080"}:                     "http://127.0.0.1:8080",
+		{ListenAddress: ":8080"}:                            "http://127.0.0.1:8080",
+		{ListenAddress: "[::]:8080"}:                        "http://127.0.0.1:8080",
+		{ListenAddress: "10.0.0.1:8080"}:                    "http://10.0.0.1:8080",
+		{ListenAddress: "localhost:8443", TLSCert: "a.pem"}: "https://localhost:8443",
+	} {
+		assert.Equal(t, expected, localURL(&cfg), cfg.ListenAddress)
+	}
+}
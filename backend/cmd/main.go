@@ -19,6 +19,13 @@ func main() {
 	cfg := &config.Config{}
 
 	flags := []cli.Flag{
+		&cli.StringFlag{
+			EnvVars:   []string{"FAUCET_CONFIG"},
+			Name:      "config",
+			TakesFile: true,
+			Usage:     "yaml config `file` (its settings are overridden by env vars and command line flags)",
+		},
+
 		&cli.StringFlag{
 			Destination: &cfg.Log.Level,
 			EnvVars:     []string{"FAUCET_LOG_LEVEL"},
@@ -38,6 +45,9 @@ func main() {
 
 	commands := []*cli.Command{
 		CommandServe(cfg),
+		CommandAPIKey(cfg),
+		CommandConfig(cfg),
+		CommandHealthcheck(cfg),
 	}
 
 	app := &cli.App{
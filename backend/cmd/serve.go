@@ -10,16 +10,174 @@ import (
 )
 
 const (
-	categoryChain  = "CHAIN:"
-	categoryFaucet = "FAUCET:"
-	categoryRedis  = "REDIS:"
-	categoryRPC    = "RPC:"
-	categoryServer = "SERVER:"
-	categoryWallet = "WALLET:"
+	categoryAdmin       = "ADMIN:"
+	categoryCaptcha     = "CAPTCHA:"
+	categoryChain       = "CHAIN:"
+	categoryCORS        = "CORS:"
+	categoryDenylist    = "DENYLIST:"
+	categoryFaucet      = "FAUCET:"
+	categoryHealth      = "HEALTH:"
+	categoryLedger      = "LEDGER:"
+	categoryMaintenance = "MAINTENANCE:"
+	categoryMetrics     = "METRICS:"
+	categoryPoW         = "POW:"
+	categoryRedis       = "REDIS:"
+	categoryReputation  = "REPUTATION:"
+	categoryRPC         = "RPC:"
+	categoryServer      = "SERVER:"
+	categorySIWE        = "SIWE:"
+	categoryTracing     = "TRACING:"
+	categoryWallet      = "WALLET:"
+	categoryWebhooks    = "WEBHOOKS:"
 )
 
+const defaultRPCEndpoint = "http://localhost:8545"
+
 func CommandServe(cfg *config.Config) *cli.Command {
+	flags, before, reload := flagsServe(cfg)
+
+	return &cli.Command{
+		Name:   "serve",
+		Usage:  "run the api server",
+		Flags:  flags,
+		Before: before,
+
+		Action: func(clictx *cli.Context) error {
+			if err := cfg.Validate(); err != nil {
+				return err
+			}
+			s, err := server.New(cfg)
+			if err != nil {
+				return err
+			}
+			if path := clictx.String("config"); path != "" {
+				s.WatchConfig(path, reload)
+			}
+			return s.Run()
+		},
+	}
+}
+
+// flagsServe returns the flags that configure the server, the hook that
+// finalises the config once the flags are parsed (e.g. applies the config
+// file), and the function that reads the config file again.
+func flagsServe(cfg *config.Config) ([]cli.Flag, cli.BeforeFunc, func() (*config.Config, error)) {
+	adminFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.AuditLogFile,
+			EnvVars:     []string{"FAUCET_ADMIN_AUDIT_LOG_FILE"},
+			Name:        "admin-audit-log-file",
+			TakesFile:   true,
+			Usage:       "`file` to append the audit log of admin actions to (in addition to the server log)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.ClientCA,
+			EnvVars:     []string{"FAUCET_ADMIN_CLIENT_CA"},
+			Name:        "admin-client-ca",
+			TakesFile:   true,
+			Usage:       "ca certificates `file` to verify the admin clients' certificates with (mtls)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.ListenAddress,
+			EnvVars:     []string{"FAUCET_ADMIN_LISTEN_ADDRESS"},
+			Name:        "admin-listen-address",
+			Usage:       "`host:port` for the admin api to listen on (disabled if omitted)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.TLSCert,
+			EnvVars:     []string{"FAUCET_ADMIN_TLS_CERT"},
+			Name:        "admin-tls-cert",
+			TakesFile:   true,
+			Usage:       "tls certificate `file` of the admin api",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.TLSKey,
+			EnvVars:     []string{"FAUCET_ADMIN_TLS_KEY"},
+			Name:        "admin-tls-key",
+			TakesFile:   true,
+			Usage:       "tls private key `file` of the admin api",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryAdmin,
+			Destination: &cfg.Admin.Token,
+			EnvVars:     []string{"FAUCET_ADMIN_TOKEN"},
+			Name:        "admin-token",
+			Usage:       "bearer `token` that authorises the admin api requests",
+		},
+	}
+
+	captchaFlags := []cli.Flag{
+		&cli.Float64Flag{
+			Category:    categoryCaptcha,
+			Destination: &cfg.Captcha.MinScore,
+			EnvVars:     []string{"FAUCET_CAPTCHA_MIN_SCORE"},
+			Name:        "captcha-min-score",
+			Usage:       "minimum recaptcha v3 `score` for the request to pass",
+			Value:       0.5,
+		},
+
+		&cli.StringFlag{
+			Category:    categoryCaptcha,
+			Destination: &cfg.Captcha.Provider,
+			EnvVars:     []string{"FAUCET_CAPTCHA_PROVIDER"},
+			Name:        "captcha-provider",
+			Usage:       "captcha `provider` to verify fund requests with (hcaptcha, recaptcha, turnstile)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryCaptcha,
+			Destination: &cfg.Captcha.Secret,
+			EnvVars:     []string{"FAUCET_CAPTCHA_SECRET"},
+			Name:        "captcha-secret",
+			Usage:       "captcha provider's `secret` key",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryCaptcha,
+			Destination: &cfg.Captcha.Timeout,
+			EnvVars:     []string{"FAUCET_CAPTCHA_TIMEOUT"},
+			Name:        "captcha-timeout",
+			Usage:       "`timeout` for captcha verification requests",
+			Value:       2 * time.Second,
+		},
+
+		&cli.StringFlag{
+			Category:    categoryCaptcha,
+			Destination: &cfg.Captcha.VerifyURL,
+			EnvVars:     []string{"FAUCET_CAPTCHA_VERIFY_URL"},
+			Name:        "captcha-verify-url",
+			Usage:       "`url` of the siteverify endpoint (default: the provider's one)",
+		},
+	}
+
 	chainFlags := []cli.Flag{
+		&cli.Uint64Flag{
+			Category:    categoryChain,
+			Destination: &cfg.Chain.Confirmations,
+			EnvVars:     []string{"FAUCET_CHAIN_CONFIRMATIONS"},
+			Name:        "chain-confirmations",
+			Usage:       "`count` of blocks (including the one with the transaction) after which the payout is confirmed",
+			Value:       3,
+		},
+
+		&cli.Uint64Flag{
+			Category:    categoryChain,
+			Destination: &cfg.Chain.ID,
+			EnvVars:     []string{"FAUCET_CHAIN_ID"},
+			Name:        "chain-id",
+			Usage:       "chain `id` the rpc must be on for the faucet to be ready (any if omitted)",
+		},
+
 		&cli.StringFlag{
 			Category:    categoryChain,
 			Destination: &cfg.Chain.Name,
@@ -29,6 +187,15 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Value:       "testnet",
 		},
 
+		&cli.UintFlag{
+			Category:    categoryChain,
+			Destination: &cfg.Chain.TokenDecimals,
+			EnvVars:     []string{"FAUCET_CHAIN_TOKEN_DECIMALS"},
+			Name:        "chain-token-decimals",
+			Usage:       "`count` of decimals of the token (i.e. one token is 10^decimals wei)",
+			Value:       18,
+		},
+
 		&cli.StringFlag{
 			Category:    categoryChain,
 			Destination: &cfg.Chain.TokenSymbol,
@@ -39,7 +206,80 @@ func CommandServe(cfg *config.Config) *cli.Command {
 		},
 	}
 
+	corsHeaders := &cli.StringSlice{}
+	corsMethods := &cli.StringSlice{}
+	corsOrigins := &cli.StringSlice{}
+	corsFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Category:    categoryCORS,
+			Destination: &cfg.CORS.AllowCredentials,
+			EnvVars:     []string{"FAUCET_CORS_ALLOW_CREDENTIALS"},
+			Name:        "cors-allow-credentials",
+			Usage:       "let the browsers send the cookies and the authorization header cross-origin",
+		},
+
+		&cli.StringSliceFlag{
+			Category:    categoryCORS,
+			Destination: corsHeaders,
+			EnvVars:     []string{"FAUCET_CORS_ALLOWED_HEADERS"},
+			Name:        "cors-allowed-headers",
+			Usage:       "request `headers` the browsers may send cross-origin (default: Authorization, Content-Type, Idempotency-Key)",
+		},
+
+		&cli.StringSliceFlag{
+			Category:    categoryCORS,
+			Destination: corsMethods,
+			EnvVars:     []string{"FAUCET_CORS_ALLOWED_METHODS"},
+			Name:        "cors-allowed-methods",
+			Usage:       "request `methods` the browsers may use cross-origin (default: GET, POST)",
+		},
+
+		&cli.StringSliceFlag{
+			Category:    categoryCORS,
+			Destination: corsOrigins,
+			EnvVars:     []string{"FAUCET_CORS_ALLOWED_ORIGINS"},
+			Name:        "cors-allowed-origins",
+			Usage:       "`origins` allowed to call the api from the browser (e.g. https://*.example.com, or * for any; cors is disabled if omitted)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryCORS,
+			Destination: &cfg.CORS.MaxAge,
+			EnvVars:     []string{"FAUCET_CORS_MAX_AGE"},
+			Name:        "cors-max-age",
+			Usage:       "`duration` the browsers may cache the preflight responses for",
+			Value:       10 * time.Minute,
+		},
+	}
+
+	denylistFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryDenylist,
+			Destination: &cfg.Denylist.File,
+			EnvVars:     []string{"FAUCET_DENYLIST_FILE"},
+			Name:        "denylist-file",
+			Usage:       "`file` with the denied addresses, identities, ip ranges and username patterns (one per line)",
+		},
+	}
+
 	faucetFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryFaucet,
+			Destination: &cfg.Faucet.Allowance,
+			EnvVars:     []string{"FAUCET_ALLOWANCE"},
+			Name:        "faucet-allowance",
+			Usage:       "`amount` of tokens every identity can receive per allowance window (unlimited if omitted)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryFaucet,
+			Destination: &cfg.Faucet.AllowanceWindow,
+			EnvVars:     []string{"FAUCET_ALLOWANCE_WINDOW"},
+			Name:        "faucet-allowance-window",
+			Usage:       "`duration` of the window over which the allowance is counted",
+			Value:       24 * time.Hour,
+		},
+
 		&cli.DurationFlag{
 			Category:    categoryFaucet,
 			Destination: &cfg.Faucet.Interval,
@@ -85,53 +325,293 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Value:       15 * time.Minute,
 		},
 
-		&cli.Int64Flag{
+		&cli.StringFlag{
 			Category:    categoryFaucet,
 			Destination: &cfg.Faucet.Payout,
 			EnvVars:     []string{"FAUCET_PAYOUT"},
 			Name:        "faucet-payout",
-			Usage:       "`number` of tokens to transfer per user request",
-			Value:       1,
+			Usage:       "`amount` of tokens to transfer per user request (e.g. 0.05, 50000000 gwei, or 1e17 wei)",
+			Value:       "1",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryFaucet,
+			Destination: &cfg.Faucet.PayoutMax,
+			EnvVars:     []string{"FAUCET_PAYOUT_MAX"},
+			Name:        "faucet-payout-max",
+			Usage:       "maximum `amount` of tokens the user can request (users can't choose the amount if omitted)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryFaucet,
+			Destination: &cfg.Faucet.PayoutMin,
+			EnvVars:     []string{"FAUCET_PAYOUT_MIN"},
+			Name:        "faucet-payout-min",
+			Usage:       "minimum `amount` of tokens the user can request (one wei if omitted)",
 		},
 	}
 
-	redisFlags := []cli.Flag{
+	healthFlags := []cli.Flag{
+		&cli.DurationFlag{
+			Category:    categoryHealth,
+			Destination: &cfg.Health.MaxHeadAge,
+			EnvVars:     []string{"FAUCET_HEALTH_MAX_HEAD_AGE"},
+			Name:        "health-max-head-age",
+			Usage:       "maximum `age` of the rpc's latest block for the faucet to be ready (0 to skip the check)",
+			Value:       2 * time.Minute,
+		},
+
 		&cli.StringFlag{
-			Category:    categoryRedis,
-			Destination: &cfg.Redis.Namespace,
-			EnvVars:     []string{"FAUCET_REDIS_NAMESPACE"},
-			Name:        "redis-namespace",
-			Usage:       "rate-limiting redis `namespace`",
-			Value:       "eth-faucet",
+			Category:    categoryHealth,
+			Destination: &cfg.Health.MinBalance,
+			EnvVars:     []string{"FAUCET_HEALTH_MIN_BALANCE"},
+			Name:        "health-min-balance",
+			Usage:       "minimum wallet balance (`amount` of tokens) for the faucet to be ready (the payout if omitted)",
+		},
+	}
+
+	ledgerFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryLedger,
+			Destination: &cfg.Ledger.Driver,
+			EnvVars:     []string{"FAUCET_LEDGER_DRIVER"},
+			Name:        "ledger-driver",
+			Usage:       "`driver` of the database to record the fund attempts into (none, postgres, sqlite)",
+			Value:       "sqlite",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryLedger,
+			Destination: &cfg.Ledger.DSN,
+			EnvVars:     []string{"FAUCET_LEDGER_DSN"},
+			Name:        "ledger-dsn",
+			Usage:       "`dsn` of the ledger database (file path for sqlite, connection string for postgres)",
+			Value:       "eth-faucet.db",
 		},
 
 		&cli.DurationFlag{
-			Category:    categoryRedis,
-			Destination: &cfg.Redis.Timeout,
-			EnvVars:     []string{"FAUCET_REDIS_TIMEOUT"},
-			Name:        "redis-timeout",
-			Usage:       "`timeout` for redis operations",
-			Value:       200 * time.Millisecond,
+			Category:    categoryLedger,
+			Destination: &cfg.Ledger.Timeout,
+			EnvVars:     []string{"FAUCET_LEDGER_TIMEOUT"},
+			Name:        "ledger-timeout",
+			Usage:       "`timeout` for ledger database operations",
+			Value:       time.Second,
+		},
+	}
+
+	maintenanceFlags := []cli.Flag{
+		&cli.BoolFlag{
+			Category:    categoryMaintenance,
+			Destination: &cfg.Maintenance.Enabled,
+			EnvVars:     []string{"FAUCET_MAINTENANCE_ENABLED"},
+			Name:        "maintenance-enabled",
+			Usage:       "refuse the fund requests (the faucet info is still served)",
 		},
 
 		&cli.StringFlag{
-			Category:    categoryRedis,
-			Destination: &cfg.Redis.URL,
-			EnvVars:     []string{"FAUCET_REDIS_URL"},
-			Name:        "redis-url",
-			Usage:       "redis `url` for rate-limiting",
-			Value:       "redis://localhost:6379",
+			Category:    categoryMaintenance,
+			Destination: &cfg.Maintenance.Message,
+			EnvVars:     []string{"FAUCET_MAINTENANCE_MESSAGE"},
+			Name:        "maintenance-message",
+			Usage:       "`message` to show to the users during the maintenance",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryMaintenance,
+			Destination: &cfg.Maintenance.ResumeAt,
+			EnvVars:     []string{"FAUCET_MAINTENANCE_RESUME_AT"},
+			Name:        "maintenance-resume-at",
+			Usage:       "expected `time` (rfc3339) of the end of the maintenance",
 		},
 	}
 
-	rpcFlags := []cli.Flag{
+	metricsFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryMetrics,
+			Destination: &cfg.Metrics.ListenAddress,
+			EnvVars:     []string{"FAUCET_METRICS_LISTEN_ADDRESS"},
+			Name:        "metrics-listen-address",
+			Usage:       "`host:port` for the prometheus metrics to be served on (disabled if omitted)",
+		},
+	}
+
+	powFlags := []cli.Flag{
+		&cli.IntFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.Difficulty,
+			EnvVars:     []string{"FAUCET_POW_DIFFICULTY"},
+			Name:        "pow-difficulty",
+			Usage:       "base `count` of leading zero bits required from the solution's hash",
+			Value:       18,
+		},
+
+		&cli.IntFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.DifficultyMax,
+			EnvVars:     []string{"FAUCET_POW_DIFFICULTY_MAX"},
+			Name:        "pow-difficulty-max",
+			Usage:       "max `count` of leading zero bits the difficulty can scale up to",
+			Value:       28,
+		},
+
+		&cli.BoolFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.Enabled,
+			EnvVars:     []string{"FAUCET_POW_ENABLED"},
+			Name:        "pow-enabled",
+			Usage:       "allow unauthenticated fund requests that solve a proof-of-work challenge",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.Secret,
+			EnvVars:     []string{"FAUCET_POW_SECRET"},
+			Name:        "pow-secret",
+			Usage:       "`secret` for signing the challenges (default: server's auth secret)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.TTL,
+			EnvVars:     []string{"FAUCET_POW_TTL"},
+			Name:        "pow-ttl",
+			Usage:       "`duration` for which an issued challenge remains valid",
+			Value:       5 * time.Minute,
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryPoW,
+			Destination: &cfg.PoW.Window,
+			EnvVars:     []string{"FAUCET_POW_WINDOW"},
+			Name:        "pow-window",
+			Usage:       "`duration` over which the recent requests volume scales the difficulty",
+			Value:       time.Hour,
+		},
+	}
+
+	redisFlags := flagsRedis(cfg)
+
+	githubOrgs := &cli.StringSlice{}
+	reputationFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.Action,
+			EnvVars:     []string{"FAUCET_REPUTATION_ACTION"},
+			Name:        "reputation-action",
+			Usage:       "`action` for identities with insufficient reputation (deny, reduce)",
+			Value:       "deny",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.CacheTTL,
+			EnvVars:     []string{"FAUCET_REPUTATION_CACHE_TTL"},
+			Name:        "reputation-cache-ttl",
+			Usage:       "`duration` for which the fetched identity profiles are cached",
+			Value:       24 * time.Hour,
+		},
+
+		&cli.BoolFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.Enabled,
+			EnvVars:     []string{"FAUCET_REPUTATION_ENABLED"},
+			Name:        "reputation-enabled",
+			Usage:       "check reputation of github and twitter identities",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.GithubAPIURL,
+			EnvVars:     []string{"FAUCET_REPUTATION_GITHUB_API_URL"},
+			Name:        "reputation-github-api-url",
+			Usage:       "github api `url`",
+			Value:       "https://api.github.com",
+		},
+
+		&cli.StringSliceFlag{
+			Category:    categoryReputation,
+			Destination: githubOrgs,
+			EnvVars:     []string{"FAUCET_REPUTATION_GITHUB_ORGS"},
+			Name:        "reputation-github-orgs",
+			Usage:       "github `orgs` at least one of which the identity must be a public member of",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.GithubToken,
+			EnvVars:     []string{"FAUCET_REPUTATION_GITHUB_TOKEN"},
+			Name:        "reputation-github-token",
+			Usage:       "github api `token` (unauthenticated api is heavily rate-limited)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.MinAccountAge,
+			EnvVars:     []string{"FAUCET_REPUTATION_MIN_ACCOUNT_AGE"},
+			Name:        "reputation-min-account-age",
+			Usage:       "minimum `age` of the identity's account",
+		},
+
+		&cli.IntFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.MinFollowers,
+			EnvVars:     []string{"FAUCET_REPUTATION_MIN_FOLLOWERS"},
+			Name:        "reputation-min-followers",
+			Usage:       "minimum `count` of the identity's followers",
+		},
+
+		&cli.IntFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.MinPublicRepos,
+			EnvVars:     []string{"FAUCET_REPUTATION_MIN_PUBLIC_REPOS"},
+			Name:        "reputation-min-public-repos",
+			Usage:       "minimum `count` of the github identity's public repos",
+		},
+
+		&cli.Int64Flag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.ReducedPayoutPercent,
+			EnvVars:     []string{"FAUCET_REPUTATION_REDUCED_PAYOUT_PERCENT"},
+			Name:        "reputation-reduced-payout-percent",
+			Usage:       "`percent` of the payout for identities with insufficient reputation (with 'reduce' action)",
+			Value:       10,
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.Timeout,
+			EnvVars:     []string{"FAUCET_REPUTATION_TIMEOUT"},
+			Name:        "reputation-timeout",
+			Usage:       "`timeout` for identity providers' api requests",
+			Value:       5 * time.Second,
+		},
+
 		&cli.StringFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.TwitterAPIURL,
+			EnvVars:     []string{"FAUCET_REPUTATION_TWITTER_API_URL"},
+			Name:        "reputation-twitter-api-url",
+			Usage:       "twitter api `url`",
+			Value:       "https://api.twitter.com",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryReputation,
+			Destination: &cfg.Reputation.TwitterBearerToken,
+			EnvVars:     []string{"FAUCET_REPUTATION_TWITTER_BEARER_TOKEN"},
+			Name:        "reputation-twitter-bearer-token",
+			Usage:       "twitter api bearer `token` (twitter identities are not checked without it)",
+		},
+	}
+
+	rpcEndpoints := &cli.StringSlice{}
+	rpcFlags := []cli.Flag{
+		&cli.StringSliceFlag{
 			Category:    categoryRPC,
-			Destination: &cfg.RPC.Endpoint,
+			Destination: rpcEndpoints,
 			EnvVars:     []string{"FAUCET_RPC_ENDPOINT"},
 			Name:        "rpc-endpoint",
-			Usage:       "`endpoint` for ethereum json-rpc connection",
-			Value:       "http://localhost:8545",
+			Usage:       "`endpoint` for ethereum json-rpc connection (repeat for the fallbacks, tried in order; default: http://localhost:8545)",
 		},
 
 		&cli.DurationFlag{
@@ -153,6 +633,24 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Usage:       "jwt authentication `secret`",
 		},
 
+		&cli.StringFlag{
+			Category:    categoryServer,
+			Destination: &cfg.Server.ClientCA,
+			EnvVars:     []string{"FAUCET_SERVER_CLIENT_CA"},
+			Name:        "server-client-ca",
+			TakesFile:   true,
+			Usage:       "ca certificates `file` to verify the certificates of the internal callers with (mtls)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryServer,
+			Destination: &cfg.Server.IdempotencyWindow,
+			EnvVars:     []string{"FAUCET_SERVER_IDEMPOTENCY_WINDOW"},
+			Name:        "server-idempotency-window",
+			Usage:       "`period` for which the fund responses are replayed to the retries with the same idempotency key (0 to ignore the keys)",
+			Value:       24 * time.Hour,
+		},
+
 		&cli.StringFlag{
 			Category:    categoryServer,
 			Destination: &cfg.Server.ListenAddress,
@@ -179,6 +677,112 @@ func CommandServe(cfg *config.Config) *cli.Command {
 			Usage:       "`count` of reverse proxies in front of the server",
 			Value:       0,
 		},
+
+		&cli.StringFlag{
+			Category:    categoryServer,
+			Destination: &cfg.Server.TLSCert,
+			EnvVars:     []string{"FAUCET_SERVER_TLS_CERT"},
+			Name:        "server-tls-cert",
+			TakesFile:   true,
+			Usage:       "tls certificate `file` (serves plain http if omitted, reloaded on change)",
+		},
+
+		&cli.StringFlag{
+			Category:    categoryServer,
+			Destination: &cfg.Server.TLSKey,
+			EnvVars:     []string{"FAUCET_SERVER_TLS_KEY"},
+			Name:        "server-tls-key",
+			TakesFile:   true,
+			Usage:       "tls key `file`",
+		},
+	}
+
+	siweFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.Domain,
+			EnvVars:     []string{"FAUCET_SIWE_DOMAIN"},
+			Name:        "siwe-domain",
+			Usage:       "`domain` that siwe messages must be issued for (e.g. faucet.example.com)",
+		},
+
+		&cli.BoolFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.EIP1271,
+			EnvVars:     []string{"FAUCET_SIWE_EIP1271"},
+			Name:        "siwe-eip1271",
+			Usage:       "verify signatures of contract wallets (eip-1271) via rpc endpoint",
+		},
+
+		&cli.BoolFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.Enabled,
+			EnvVars:     []string{"FAUCET_SIWE_ENABLED"},
+			Name:        "siwe-enabled",
+			Usage:       "enable sign-in with ethereum (eip-4361)",
+		},
+
+		&cli.Uint64Flag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.MainnetMinTxCount,
+			EnvVars:     []string{"FAUCET_SIWE_MAINNET_MIN_TX_COUNT"},
+			Name:        "siwe-mainnet-min-tx-count",
+			Usage:       "minimum `count` of mainnet transactions the signer must have sent",
+		},
+
+		&cli.StringFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.MainnetRPCEndpoint,
+			EnvVars:     []string{"FAUCET_SIWE_MAINNET_RPC_ENDPOINT"},
+			Name:        "siwe-mainnet-rpc-endpoint",
+			Usage:       "`endpoint` of mainnet json-rpc for checking the signer's history",
+		},
+
+		&cli.DurationFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.NonceTTL,
+			EnvVars:     []string{"FAUCET_SIWE_NONCE_TTL"},
+			Name:        "siwe-nonce-ttl",
+			Usage:       "`duration` for which an issued siwe nonce remains valid",
+			Value:       5 * time.Minute,
+		},
+
+		&cli.DurationFlag{
+			Category:    categorySIWE,
+			Destination: &cfg.SIWE.SessionTTL,
+			EnvVars:     []string{"FAUCET_SIWE_SESSION_TTL"},
+			Name:        "siwe-session-ttl",
+			Usage:       "`duration` for which the session token minted after siwe is valid",
+			Value:       time.Hour,
+		},
+	}
+
+	tracingFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryTracing,
+			Destination: &cfg.Tracing.Endpoint,
+			EnvVars:     []string{"FAUCET_TRACING_ENDPOINT"},
+			Name:        "tracing-endpoint",
+			Usage:       "otlp/http `url` to export the traces to, e.g. http://localhost:4318 (disabled if omitted)",
+		},
+
+		&cli.Float64Flag{
+			Category:    categoryTracing,
+			Destination: &cfg.Tracing.SampleRatio,
+			EnvVars:     []string{"FAUCET_TRACING_SAMPLE_RATIO"},
+			Name:        "tracing-sample-ratio",
+			Usage:       "`ratio` of the requests to trace (unless the caller has decided already)",
+			Value:       1,
+		},
+
+		&cli.StringFlag{
+			Category:    categoryTracing,
+			Destination: &cfg.Tracing.ServiceName,
+			EnvVars:     []string{"FAUCET_TRACING_SERVICE_NAME"},
+			Name:        "tracing-service-name",
+			Usage:       "service `name` to report the traces under",
+			Value:       "eth-faucet",
+		},
 	}
 
 	walletFlags := []cli.Flag{
@@ -207,26 +811,118 @@ func CommandServe(cfg *config.Config) *cli.Command {
 		},
 	}
 
+	webhooksFlags := []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryWebhooks,
+			Destination: &cfg.Webhooks.LowBalance,
+			EnvVars:     []string{"FAUCET_WEBHOOKS_LOW_BALANCE"},
+			Name:        "webhooks-low-balance",
+			Usage:       "wallet balance (`amount` of tokens) below which the wallet.low_balance event is sent (the health's minimum balance if omitted)",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryWebhooks,
+			Destination: &cfg.Webhooks.RetryTimeout,
+			EnvVars:     []string{"FAUCET_WEBHOOKS_RETRY_TIMEOUT"},
+			Name:        "webhooks-retry-timeout",
+			Usage:       "`period` over which the failed webhook deliveries are retried",
+			Value:       10 * time.Minute,
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryWebhooks,
+			Destination: &cfg.Webhooks.Timeout,
+			EnvVars:     []string{"FAUCET_WEBHOOKS_TIMEOUT"},
+			Name:        "webhooks-timeout",
+			Usage:       "`timeout` for webhook requests",
+			Value:       5 * time.Second,
+		},
+	}
+
 	flags := slices.Concat(
+		adminFlags,
+		captchaFlags,
 		chainFlags,
+		corsFlags,
+		denylistFlags,
 		faucetFlags,
+		healthFlags,
+		ledgerFlags,
+		maintenanceFlags,
+		metricsFlags,
+		powFlags,
 		redisFlags,
+		reputationFlags,
 		rpcFlags,
 		serverFlags,
+		siweFlags,
+		tracingFlags,
 		walletFlags,
+		webhooksFlags,
 	)
 
-	return &cli.Command{
-		Name:  "serve",
-		Usage: "run the api server",
-		Flags: flags,
+	var reloadConfigFile func() (*config.Config, error)
 
-		Action: func(_ *cli.Context) error {
-			s, err := server.New(cfg)
-			if err != nil {
-				return err
+	before := func(clictx *cli.Context) (err error) {
+		reloadConfigFile, err = loadConfigFile(clictx, cfg, func(cfg *config.Config) {
+			if clictx.IsSet("cors-allowed-headers") {
+				cfg.CORS.AllowedHeaders = corsHeaders.Value()
 			}
-			return s.Run()
+			if clictx.IsSet("cors-allowed-methods") {
+				cfg.CORS.AllowedMethods = corsMethods.Value()
+			}
+			if clictx.IsSet("cors-allowed-origins") {
+				cfg.CORS.AllowedOrigins = corsOrigins.Value()
+			}
+			if clictx.IsSet("reputation-github-orgs") {
+				cfg.Reputation.GithubOrgs = githubOrgs.Value()
+			}
+			if clictx.IsSet("rpc-endpoint") {
+				cfg.RPC.Endpoints = rpcEndpoints.Value()
+			} else if len(cfg.RPC.Endpoints) == 0 {
+				cfg.RPC.Endpoints = []string{defaultRPCEndpoint}
+			}
+		})
+		return err
+	}
+
+	reload := func() (*config.Config, error) {
+		if reloadConfigFile == nil {
+			return nil, ErrConfigFileMissing
+		}
+		return reloadConfigFile()
+	}
+
+	return flags, before, reload
+}
+
+func flagsRedis(cfg *config.Config) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Category:    categoryRedis,
+			Destination: &cfg.Redis.Namespace,
+			EnvVars:     []string{"FAUCET_REDIS_NAMESPACE"},
+			Name:        "redis-namespace",
+			Usage:       "rate-limiting redis `namespace`",
+			Value:       "eth-faucet",
+		},
+
+		&cli.DurationFlag{
+			Category:    categoryRedis,
+			Destination: &cfg.Redis.Timeout,
+			EnvVars:     []string{"FAUCET_REDIS_TIMEOUT"},
+			Name:        "redis-timeout",
+			Usage:       "`timeout` for redis operations",
+			Value:       200 * time.Millisecond,
+		},
+
+		&cli.StringFlag{
+			Category:    categoryRedis,
+			Destination: &cfg.Redis.URL,
+			EnvVars:     []string{"FAUCET_REDIS_URL"},
+			Name:        "redis-url",
+			Usage:       "redis `url` for rate-limiting",
+			Value:       "redis://localhost:6379",
 		},
 	}
 }
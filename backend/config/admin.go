@@ -0,0 +1,34 @@
+package config
+
+type Admin struct {
+	AuditLogFile  string `yaml:"audit_log_file"`
+	ClientCA      string `yaml:"client_ca"`
+	ListenAddress string `yaml:"listen_address"`
+	TLSCert       string `yaml:"tls_cert"`
+	TLSKey        string `yaml:"tls_key"`
+	Token         string `yaml:"token"`
+}
+
+func (a Admin) Enabled() bool {
+	return a.ListenAddress != ""
+}
+
+func (a Admin) validate() []error {
+	errs := make([]error, 0)
+	if !a.Enabled() {
+		return errs
+	}
+	if err := validateHostPort("admin.listen_address", a.ListenAddress); err != nil {
+		errs = append(errs, err)
+	}
+	if a.Token == "" && a.ClientCA == "" {
+		errs = append(errs, invalid("admin", "either token or client_ca must be set"))
+	}
+	if (a.TLSCert == "") != (a.TLSKey == "") {
+		errs = append(errs, invalid("admin", "tls_cert and tls_key must be set together"))
+	}
+	if a.ClientCA != "" && a.TLSCert == "" {
+		errs = append(errs, invalid("admin.client_ca", "requires tls_cert and tls_key"))
+	}
+	return errs
+}
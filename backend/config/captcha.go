@@ -0,0 +1,37 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type Captcha struct {
+	MinScore  float64       `yaml:"min_score"`
+	Provider  string        `yaml:"provider"`
+	Secret    string        `yaml:"secret"`
+	Timeout   time.Duration `yaml:"timeout"`
+	VerifyURL string        `yaml:"verify_url"`
+}
+
+func (c Captcha) validate() []error {
+	errs := make([]error, 0)
+	if c.Provider == "" {
+		return errs
+	}
+	if !slices.Contains([]string{"hcaptcha", "recaptcha", "turnstile"}, strings.ToLower(c.Provider)) {
+		errs = append(errs, invalid("captcha.provider", "must be one of hcaptcha, recaptcha, turnstile: %s", c.Provider))
+	}
+	if c.Secret == "" {
+		errs = append(errs, invalid("captcha.secret", "must not be empty when provider is set"))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, invalid("captcha.timeout", "must be positive"))
+	}
+	if c.VerifyURL != "" {
+		if err := validateURL("captcha.verify_url", c.VerifyURL, "http", "https"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
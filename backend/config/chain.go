@@ -1,6 +1,23 @@
 package config
 
 type Chain struct {
-	Name        string `yaml:"name"`
-	TokenSymbol string `yaml:"token_symbol"`
+	Confirmations uint64 `yaml:"confirmations"`
+	ID            uint64 `yaml:"id"`
+	Name          string `yaml:"name"`
+	TokenDecimals uint   `yaml:"token_decimals"`
+	TokenSymbol   string `yaml:"token_symbol"`
+}
+
+func (c Chain) validate() []error {
+	errs := make([]error, 0)
+	if c.Name == "" {
+		errs = append(errs, invalid("chain.name", "must not be empty"))
+	}
+	if c.TokenDecimals > 77 {
+		errs = append(errs, invalid("chain.token_decimals", "must not exceed 77"))
+	}
+	if c.TokenSymbol == "" {
+		errs = append(errs, invalid("chain.token_symbol", "must not be empty"))
+	}
+	return errs
 }
@@ -1,11 +1,24 @@
 package config
 
 type Config struct {
-	Chain  Chain  `yaml:"chain"`
-	Faucet Faucet `yaml:"faucet"`
-	Log    Log    `yaml:"log"`
-	Redis  Redis  `yaml:"redis"`
-	RPC    RPC    `yaml:"rpc"`
-	Server Server `yaml:"server"`
-	Wallet Wallet `yaml:"wallet"`
+	Admin       Admin       `yaml:"admin"`
+	Captcha     Captcha     `yaml:"captcha"`
+	Chain       Chain       `yaml:"chain"`
+	CORS        CORS        `yaml:"cors"`
+	Denylist    Denylist    `yaml:"denylist"`
+	Faucet      Faucet      `yaml:"faucet"`
+	Health      Health      `yaml:"health"`
+	Ledger      Ledger      `yaml:"ledger"`
+	Log         Log         `yaml:"log"`
+	Maintenance Maintenance `yaml:"maintenance"`
+	Metrics     Metrics     `yaml:"metrics"`
+	PoW         PoW         `yaml:"pow"`
+	Redis       Redis       `yaml:"redis"`
+	Reputation  Reputation  `yaml:"reputation"`
+	RPC         RPC         `yaml:"rpc"`
+	Server      Server      `yaml:"server"`
+	SIWE        SIWE        `yaml:"siwe"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Wallet      Wallet      `yaml:"wallet"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
 }
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type CORS struct {
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	AllowedMethods   []string      `yaml:"allowed_methods"`
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+var (
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Idempotency-Key"}
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost}
+)
+
+func (c CORS) Enabled() bool {
+	return len(c.AllowedOrigins) > 0
+}
+
+// Headers returns the request headers the browsers may send, which default to
+// the ones the api needs.
+func (c CORS) Headers() []string {
+	if len(c.AllowedHeaders) == 0 {
+		return defaultCORSHeaders
+	}
+	return c.AllowedHeaders
+}
+
+// Methods returns the request methods the browsers may use, which default to
+// the ones the api needs.
+func (c CORS) Methods() []string {
+	if len(c.AllowedMethods) == 0 {
+		return defaultCORSMethods
+	}
+	return c.AllowedMethods
+}
+
+func (c CORS) validate() []error {
+	errs := make([]error, 0)
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				// the browsers reject credentialed responses for any origin
+				errs = append(errs, invalid("cors.allowed_origins", "must list the origins explicitly when credentials are allowed"))
+			}
+			continue
+		}
+		u, err := url.Parse(strings.Replace(origin, "://*.", "://", 1))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.User != nil {
+			errs = append(errs, invalid("cors.allowed_origins", "must be `*` or scheme://host[:port] (optionally with a `*.` subdomain wildcard): %s", origin))
+		}
+	}
+	for _, method := range c.AllowedMethods {
+		if method == "" || strings.ToUpper(method) != method {
+			errs = append(errs, invalid("cors.allowed_methods", "must be upper-case http methods: %s", method))
+		}
+	}
+	if c.MaxAge < 0 {
+		errs = append(errs, invalid("cors.max_age", "must not be negative: %s", c.MaxAge))
+	}
+	return errs
+}
@@ -0,0 +1,20 @@
+package config
+
+import (
+	"os"
+)
+
+type Denylist struct {
+	File string `yaml:"file"`
+}
+
+func (d Denylist) validate() []error {
+	errs := make([]error, 0)
+	if d.File == "" {
+		return errs
+	}
+	if _, err := os.Stat(d.File); err != nil {
+		errs = append(errs, invalid("denylist.file", "%w", err))
+	}
+	return errs
+}
@@ -1,21 +1,139 @@
 package config
 
 import (
+	"fmt"
 	"math/big"
+	"slices"
 	"time"
+
+	"github.com/flashbots/eth-faucet/units"
 )
 
 type Faucet struct {
+	Allowance                  string        `yaml:"allowance"`
+	AllowanceWindow            time.Duration `yaml:"allowance_window"`
 	Interval                   time.Duration `yaml:"interval"`
 	IntervalAddress            time.Duration `yaml:"interval_address"`
 	IntervalIdentity           time.Duration `yaml:"interval_identity"`
 	IntervalIdentityAndAddress time.Duration `yaml:"interval_identity_and_address"`
 	IntervalIP                 time.Duration `yaml:"interval_ip"`
-	Payout                     int64         `yaml:"payout"`
+	Payout                     string        `yaml:"payout"`
+	PayoutMax                  string        `yaml:"payout_max"`
+	PayoutMin                  string        `yaml:"payout_min"`
+
+	// Policies override the settings above for the identities of particular
+	// providers (and tiers).
+	Policies []Policy `yaml:"policies"`
+}
+
+// PayoutWei converts the payout (e.g. "0.05" tokens or "50000000 gwei") into
+// wei.
+func (f Faucet) PayoutWei(decimals uint) (*big.Int, error) {
+	return units.Parse(f.Payout, decimals)
+}
+
+// PayoutRange returns the bounds of the amount the users can choose (nil if
+// they can't).
+func (f Faucet) PayoutRange(decimals uint) (*big.Int, *big.Int, error) {
+	if f.PayoutMax == "" {
+		return nil, nil, nil
+	}
+	_max, err := units.Parse(f.PayoutMax, decimals)
+	if err != nil {
+		return nil, nil, err
+	}
+	_min := big.NewInt(1)
+	if f.PayoutMin != "" {
+		if _min, err = units.Parse(f.PayoutMin, decimals); err != nil {
+			return nil, nil, err
+		}
+	}
+	return _min, _max, nil
+}
+
+// AllowanceWei returns the amount every identity can receive during the
+// allowance window (nil if unlimited).
+func (f Faucet) AllowanceWei(decimals uint) (*big.Int, error) {
+	if f.Allowance == "" {
+		return nil, nil
+	}
+	return units.Parse(f.Allowance, decimals)
+}
+
+func (f Faucet) validate(decimals uint) []error {
+	errs := f.validateSettings("faucet", decimals)
+	if len(errs) > 0 {
+		// the policies inherit the same errors
+		return errs
+	}
+	for i, p := range f.Policies {
+		section := fmt.Sprintf("faucet.policies[%d]", i)
+		if p.Provider == "" {
+			errs = append(errs, invalid(section+".provider", "must not be empty"))
+		}
+		if slices.ContainsFunc(f.Policies[:i], func(prev Policy) bool {
+			return prev.Provider == p.Provider && prev.Tier == p.Tier
+		}) {
+			errs = append(errs, invalid(section, "duplicates the policy for %s", p))
+		}
+		errs = append(errs, f.PolicyFor(p.Provider, p.Tier).validateSettings(section, decimals)...)
+	}
+	return errs
 }
 
-func (f Faucet) PayoutWei() *big.Int {
-	bigInt := big.NewInt(0)
-	eth := bigInt.Exp(big.NewInt(10), big.NewInt(18), nil)
-	return bigInt.Mul(big.NewInt(f.Payout), eth)
+func (f Faucet) validateSettings(section string, decimals uint) []error {
+	errs := make([]error, 0)
+	allowance, err := f.AllowanceWei(decimals)
+	if err != nil {
+		errs = append(errs, invalid(section+".allowance", "%w", err))
+	} else if allowance != nil && allowance.Sign() <= 0 {
+		errs = append(errs, invalid(section+".allowance", "must be positive"))
+	}
+	if allowance != nil && f.AllowanceWindow <= 0 {
+		errs = append(errs, invalid(section+".allowance_window", "must be positive"))
+	}
+	if f.Interval <= 0 {
+		errs = append(errs, invalid(section+".interval", "must be positive"))
+	}
+	if f.IntervalAddress < 0 {
+		errs = append(errs, invalid(section+".interval_address", "must not be negative"))
+	}
+	if f.IntervalIdentity < 0 {
+		errs = append(errs, invalid(section+".interval_identity", "must not be negative"))
+	}
+	if f.IntervalIdentityAndAddress < 0 {
+		errs = append(errs, invalid(section+".interval_identity_and_address", "must not be negative"))
+	}
+	if f.IntervalIP < 0 {
+		errs = append(errs, invalid(section+".interval_ip", "must not be negative"))
+	}
+	payout, err := f.PayoutWei(decimals)
+	if err != nil {
+		errs = append(errs, invalid(section+".payout", "%w", err))
+	} else if payout.Sign() <= 0 {
+		errs = append(errs, invalid(section+".payout", "must be positive"))
+	}
+	if f.PayoutMin != "" && f.PayoutMax == "" {
+		errs = append(errs, invalid(section+".payout_min", "requires payout_max"))
+	}
+	_min, _max, err := f.PayoutRange(decimals)
+	switch {
+	case err != nil:
+		errs = append(errs, invalid(section+".payout_max", "%w", err))
+	case _max == nil:
+		// users can't choose the amount
+	case _min.Sign() <= 0 || _min.Cmp(_max) > 0:
+		errs = append(errs, invalid(section+".payout_min", "must be positive and not exceed payout_max"))
+	case payout != nil && (payout.Cmp(_min) < 0 || payout.Cmp(_max) > 0):
+		errs = append(errs, invalid(section+".payout", "must be within payout_min and payout_max"))
+	}
+	if allowance != nil && allowance.Sign() > 0 {
+		if _max != nil && _max.Cmp(allowance) > 0 {
+			errs = append(errs, invalid(section+".payout_max", "must not exceed allowance"))
+		}
+		if payout != nil && payout.Cmp(allowance) > 0 {
+			errs = append(errs, invalid(section+".payout", "must not exceed allowance"))
+		}
+	}
+	return errs
 }
@@ -2,6 +2,7 @@ package config_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/flashbots/eth-faucet/config"
 	"github.com/stretchr/testify/assert"
@@ -9,10 +10,61 @@ import (
 
 func TestPayoutWei(t *testing.T) {
 	f := config.Faucet{
-		Payout: 10,
+		Payout: "10",
 	}
-	val1, _ := f.PayoutWei().Float64()
-	assert.Equal(t, 1e+19, val1)
-	val2, _ := f.PayoutWei().Float64()
-	assert.Equal(t, 1e+19, val2)
+	val1, _ := f.PayoutWei(18)
+	assert.Equal(t, "10000000000000000000", val1.String())
+	val2, _ := f.PayoutWei(18)
+	assert.Equal(t, "10000000000000000000", val2.String())
+
+	f.Payout = "0.05"
+	val3, _ := f.PayoutWei(18)
+	assert.Equal(t, "50000000000000000", val3.String())
+	val4, _ := f.PayoutWei(6)
+	assert.Equal(t, "50000", val4.String())
+
+	f.Payout = "50000000 gwei"
+	val5, _ := f.PayoutWei(18)
+	assert.Equal(t, "50000000000000000", val5.String())
+}
+
+func TestPayoutRange(t *testing.T) {
+	f := config.Faucet{
+		Payout: "0.05",
+	}
+	_min, _max, err := f.PayoutRange(18)
+	assert.NoError(t, err)
+	assert.Nil(t, _min)
+	assert.Nil(t, _max)
+
+	f.PayoutMin = "0.01"
+	f.PayoutMax = "1000000000 gwei"
+	_min, _max, err = f.PayoutRange(18)
+	assert.NoError(t, err)
+	assert.Equal(t, "10000000000000000", _min.String())
+	assert.Equal(t, "1000000000000000000", _max.String())
+}
+
+func TestPolicyFor(t *testing.T) {
+	f := config.Faucet{
+		Interval:   15 * time.Minute,
+		IntervalIP: time.Hour,
+		Payout:     "0.05",
+		Policies: []config.Policy{
+			{Provider: "github", Tier: "org", Payout: "1", IntervalIP: time.Minute},
+			{Provider: "github", Payout: "0.1", Interval: time.Hour},
+			{Provider: "twitter", Payout: "0.01"},
+		},
+	}
+
+	assert.Equal(t, "0.05", f.PolicyFor("siwe", "").Payout)
+	assert.Equal(t, "0.1", f.PolicyFor("github", "").Payout)
+	assert.Equal(t, "0.1", f.PolicyFor("github", "unknown").Payout)
+	assert.Equal(t, "1", f.PolicyFor("github", "org").Payout)
+	assert.Equal(t, 15*time.Minute, f.PolicyFor("github", "org").Interval)
+	assert.Equal(t, time.Hour, f.PolicyFor("github", "").Interval)
+	assert.Equal(t, time.Minute, f.PolicyFor("github", "org").IntervalIP)
+	assert.Equal(t, time.Hour, f.PolicyFor("github", "").IntervalIP)
+	assert.Equal(t, "0.01", f.PolicyFor("twitter", "org").Payout)
+	assert.Nil(t, f.PolicyFor("github", "").Policies)
 }
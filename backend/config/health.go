@@ -0,0 +1,35 @@
+package config
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/flashbots/eth-faucet/units"
+)
+
+type Health struct {
+	MaxHeadAge time.Duration `yaml:"max_head_age"`
+	MinBalance string        `yaml:"min_balance"`
+}
+
+// MinBalanceWei returns the balance below which the faucet is not ready,
+// which defaults to the payout.
+func (h Health) MinBalanceWei(faucet Faucet, decimals uint) (*big.Int, error) {
+	if h.MinBalance == "" {
+		return faucet.PayoutWei(decimals)
+	}
+	return units.Parse(h.MinBalance, decimals)
+}
+
+func (h Health) validate(decimals uint) []error {
+	errs := make([]error, 0)
+	if h.MaxHeadAge < 0 {
+		errs = append(errs, invalid("health.max_head_age", "must not be negative: %s", h.MaxHeadAge))
+	}
+	if h.MinBalance != "" {
+		if _, err := units.Parse(h.MinBalance, decimals); err != nil {
+			errs = append(errs, invalid("health.min_balance", "%w", err))
+		}
+	}
+	return errs
+}
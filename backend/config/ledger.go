@@ -0,0 +1,37 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type Ledger struct {
+	DSN     string        `yaml:"dsn"`
+	Driver  string        `yaml:"driver"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Enabled tells whether the fund attempts are recorded ("none" switches the
+// ledger off).
+func (l Ledger) Enabled() bool {
+	driver := strings.ToLower(l.Driver)
+	return driver != "" && driver != "none"
+}
+
+func (l Ledger) validate() []error {
+	errs := make([]error, 0)
+	if !l.Enabled() {
+		return errs
+	}
+	if !slices.Contains([]string{"postgres", "sqlite"}, strings.ToLower(l.Driver)) {
+		errs = append(errs, invalid("ledger.driver", "must be one of none, postgres, sqlite: %s", l.Driver))
+	}
+	if l.DSN == "" {
+		errs = append(errs, invalid("ledger.dsn", "must not be empty when driver is set"))
+	}
+	if l.Timeout <= 0 {
+		errs = append(errs, invalid("ledger.timeout", "must be positive"))
+	}
+	return errs
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrFailedToParseConfigFile = errors.New("failed to parse config file")
+	ErrFailedToReadConfigFile  = errors.New("failed to read config file")
+)
+
+// LoadFile reads the yaml config file on top of the values already present in
+// cfg.  Only the settings that are present in the file are overwritten.
+func LoadFile(path string, cfg *Config) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToReadConfigFile, err)
+	}
+
+	d := yaml.NewDecoder(bytes.NewReader(b))
+	d.KnownFields(true)
+	if err := d.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: %s: %w", ErrFailedToParseConfigFile, path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+faucet:
+  interval: 1m
+reputation:
+  github_orgs: [flashbots, ethereum]
+`), 0o600))
+
+	cfg := &config.Config{
+		Faucet: config.Faucet{
+			Interval:        15 * time.Minute,
+			IntervalAddress: 15 * time.Minute,
+		},
+	}
+	require.NoError(t, config.LoadFile(path, cfg))
+	assert.Equal(t, time.Minute, cfg.Faucet.Interval)
+	assert.Equal(t, 15*time.Minute, cfg.Faucet.IntervalAddress)
+	assert.Equal(t, []string{"flashbots", "ethereum"}, cfg.Reputation.GithubOrgs)
+
+	require.NoError(t, os.WriteFile(path, []byte("faucet:\n  intervall: 1m\n"), 0o600))
+	assert.ErrorIs(t, config.LoadFile(path, cfg), config.ErrFailedToParseConfigFile)
+}
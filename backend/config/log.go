@@ -1,6 +1,22 @@
 package config
 
+import (
+	"slices"
+	"strings"
+)
+
 type Log struct {
 	Level string `yaml:"level"`
 	Mode  string `yaml:"mode"`
 }
+
+func (l Log) validate() []error {
+	errs := make([]error, 0)
+	if !slices.Contains([]string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}, strings.ToLower(l.Level)) {
+		errs = append(errs, invalid("log.level", "must be one of debug, info, warn, error: %s", l.Level))
+	}
+	if !slices.Contains([]string{"dev", "prod"}, strings.ToLower(l.Mode)) {
+		errs = append(errs, invalid("log.mode", "must be one of dev, prod: %s", l.Mode))
+	}
+	return errs
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Maintenance struct {
+	Enabled  bool     `yaml:"enabled"`
+	Message  string   `yaml:"message"`
+	ResumeAt string   `yaml:"resume_at"`
+	Schedule Schedule `yaml:"schedule"`
+}
+
+// Schedule lists the windows when the faucet is open.  Empty schedule keeps
+// the faucet open all the time.
+type Schedule struct {
+	Message  string   `yaml:"message"`
+	Timezone string   `yaml:"timezone"`
+	Windows  []Window `yaml:"windows"`
+}
+
+// Window is a daily (or, with days set, weekly) opening window.  A window
+// that ends before it starts closes on the next day.
+type Window struct {
+	Days []string `yaml:"days,omitempty"`
+	From string   `yaml:"from"`
+	To   string   `yaml:"to"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ResumeTime returns the announced end of the maintenance (zero if there is
+// none).
+func (m Maintenance) ResumeTime() (time.Time, error) {
+	if m.ResumeAt == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, m.ResumeAt)
+}
+
+// Location returns the timezone of the schedule (utc by default).
+func (s Schedule) Location() (*time.Location, error) {
+	if s.Timezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.Timezone)
+}
+
+// Weekdays returns the days when the window opens (nil for every day).
+func (w Window) Weekdays() ([]time.Weekday, error) {
+	if len(w.Days) == 0 {
+		return nil, nil
+	}
+	res := make([]time.Weekday, 0, len(w.Days))
+	for _, day := range w.Days {
+		weekday, known := weekdays[strings.ToLower(day)]
+		if !known {
+			return nil, fmt.Errorf("unknown day: %s", day)
+		}
+		res = append(res, weekday)
+	}
+	return res, nil
+}
+
+// Times returns the opening and closing times of the window as offsets from
+// the midnight.
+func (w Window) Times() (from, to time.Duration, err error) {
+	if from, err = parseTimeOfDay(w.From); err != nil {
+		return 0, 0, err
+	}
+	if to, err = parseTimeOfDay(w.To); err != nil {
+		return 0, 0, err
+	}
+	return from, to, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day (expected hh:mm): %s", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (m Maintenance) validate() []error {
+	errs := make([]error, 0)
+	if _, err := m.ResumeTime(); err != nil {
+		errs = append(errs, invalid("maintenance.resume_at", "must be an rfc3339 time: %s", m.ResumeAt))
+	}
+	if _, err := m.Schedule.Location(); err != nil {
+		errs = append(errs, invalid("maintenance.schedule.timezone", "%w", err))
+	}
+	for i, w := range m.Schedule.Windows {
+		field := fmt.Sprintf("maintenance.schedule.windows[%d]", i)
+		if _, err := w.Weekdays(); err != nil {
+			errs = append(errs, invalid(field+".days", "%w", err))
+		}
+		from, to, err := w.Times()
+		if err != nil {
+			errs = append(errs, invalid(field, "%w", err))
+			continue
+		}
+		if from == to {
+			errs = append(errs, invalid(field, "must not open and close at the same time"))
+		}
+	}
+	return errs
+}
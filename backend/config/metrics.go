@@ -0,0 +1,20 @@
+package config
+
+type Metrics struct {
+	ListenAddress string `yaml:"listen_address"`
+}
+
+func (m Metrics) Enabled() bool {
+	return m.ListenAddress != ""
+}
+
+func (m Metrics) validate() []error {
+	errs := make([]error, 0)
+	if !m.Enabled() {
+		return errs
+	}
+	if err := validateHostPort("metrics.listen_address", m.ListenAddress); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
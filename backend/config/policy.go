@@ -0,0 +1,87 @@
+package config
+
+import "time"
+
+// Policy overrides the faucet settings for the identities of the provider
+// (e.g. "github"), and optionally only for those with the tier claim in their
+// jwt.  The empty (zero) settings are inherited from the faucet.
+type Policy struct {
+	Provider string `yaml:"provider"`
+	Tier     string `yaml:"tier,omitempty"`
+
+	Allowance                  string        `yaml:"allowance,omitempty"`
+	AllowanceWindow            time.Duration `yaml:"allowance_window,omitempty"`
+	Interval                   time.Duration `yaml:"interval,omitempty"`
+	IntervalAddress            time.Duration `yaml:"interval_address,omitempty"`
+	IntervalIdentity           time.Duration `yaml:"interval_identity,omitempty"`
+	IntervalIdentityAndAddress time.Duration `yaml:"interval_identity_and_address,omitempty"`
+	IntervalIP                 time.Duration `yaml:"interval_ip,omitempty"`
+	Payout                     string        `yaml:"payout,omitempty"`
+	PayoutMax                  string        `yaml:"payout_max,omitempty"`
+	PayoutMin                  string        `yaml:"payout_min,omitempty"`
+}
+
+func (p Policy) String() string {
+	if p.Tier == "" {
+		return p.Provider
+	}
+	return p.Provider + ":" + p.Tier
+}
+
+// PolicyFor returns the faucet settings that apply to the identity.  The
+// policy for the provider and tier takes precedence over the one for the
+// provider alone.
+func (f Faucet) PolicyFor(provider, tier string) Faucet {
+	res := f
+	res.Policies = nil
+
+	var policy *Policy
+	for i, p := range f.Policies {
+		if p.Provider != provider {
+			continue
+		}
+		if p.Tier == tier && tier != "" {
+			policy = &f.Policies[i]
+			break
+		}
+		if p.Tier == "" {
+			policy = &f.Policies[i]
+		}
+	}
+	if policy == nil {
+		return res
+	}
+
+	if policy.Allowance != "" {
+		res.Allowance = policy.Allowance
+	}
+	if policy.AllowanceWindow != 0 {
+		res.AllowanceWindow = policy.AllowanceWindow
+	}
+	if policy.Interval != 0 {
+		res.Interval = policy.Interval
+	}
+	if policy.IntervalAddress != 0 {
+		res.IntervalAddress = policy.IntervalAddress
+	}
+	if policy.IntervalIdentity != 0 {
+		res.IntervalIdentity = policy.IntervalIdentity
+	}
+	if policy.IntervalIdentityAndAddress != 0 {
+		res.IntervalIdentityAndAddress = policy.IntervalIdentityAndAddress
+	}
+	if policy.IntervalIP != 0 {
+		res.IntervalIP = policy.IntervalIP
+	}
+	if policy.Payout != "" {
+		res.Payout = policy.Payout
+	}
+	if policy.PayoutMax != "" {
+		res.PayoutMax = policy.PayoutMax
+	}
+	if policy.PayoutMin != "" {
+		res.PayoutMin = policy.PayoutMin
+	}
+
+	return res
+}
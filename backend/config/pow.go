@@ -0,0 +1,32 @@
+package config
+
+import "time"
+
+type PoW struct {
+	Difficulty    int           `yaml:"difficulty"`
+	DifficultyMax int           `yaml:"difficulty_max"`
+	Enabled       bool          `yaml:"enabled"`
+	Secret        string        `yaml:"secret"`
+	TTL           time.Duration `yaml:"ttl"`
+	Window        time.Duration `yaml:"window"`
+}
+
+func (p PoW) validate() []error {
+	errs := make([]error, 0)
+	if !p.Enabled {
+		return errs
+	}
+	if p.Difficulty < 1 || p.Difficulty > 256 {
+		errs = append(errs, invalid("pow.difficulty", "must be between 1 and 256"))
+	}
+	if p.DifficultyMax != 0 && p.DifficultyMax < p.Difficulty {
+		errs = append(errs, invalid("pow.difficulty_max", "must not be less than pow.difficulty"))
+	}
+	if p.TTL <= 0 {
+		errs = append(errs, invalid("pow.ttl", "must be positive"))
+	}
+	if p.Window <= 0 {
+		errs = append(errs, invalid("pow.window", "must be positive"))
+	}
+	return errs
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const redacted = "<redacted>"
+
+var secretFieldMarkers = []string{"password", "private_key", "secret", "token"}
+
+// the password in the key=value connection strings (e.g. postgres dsn)
+var passwordParameter = regexp.MustCompile(`(?i)(\bpassword=)('[^']*'|\S+)`)
+
+// Redacted returns a copy of the config with the secrets replaced by a
+// placeholder, so that it can be printed or logged.
+func (c Config) Redacted() Config {
+	res := c
+	redactStruct(reflect.ValueOf(&res).Elem())
+
+	res.Ledger.DSN = redactConnectionString(res.Ledger.DSN)
+	res.Redis.URL = redactConnectionString(res.Redis.URL)
+
+	return res
+}
+
+func redactConnectionString(s string) string {
+	if u, err := url.Parse(s); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return strings.Replace(u.String(), url.QueryEscape(redacted), redacted, 1)
+		}
+	}
+	return passwordParameter.ReplaceAllString(s, "${1}"+redacted)
+}
+
+func redactStruct(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.Struct:
+			redactStruct(field)
+		case reflect.Slice:
+			if field.Type().Elem().Kind() != reflect.Struct || field.IsNil() {
+				continue
+			}
+			// copy first, so that the original config is left intact
+			elems := reflect.MakeSlice(field.Type(), field.Len(), field.Len())
+			reflect.Copy(elems, field)
+			for j := 0; j < elems.Len(); j++ {
+				redactStruct(elems.Index(j))
+			}
+			field.Set(elems)
+		case reflect.String:
+			if field.String() != "" && isSecretField(t.Field(i).Tag.Get("yaml")) {
+				field.SetString(redacted)
+			}
+		}
+	}
+}
+
+func isSecretField(tag string) bool {
+	name, _, _ := strings.Cut(tag, ",")
+	for _, marker := range secretFieldMarkers {
+		if strings.HasSuffix(name, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,3 +7,14 @@ type Redis struct {
 	Timeout   time.Duration `yaml:"timeout"`
 	URL       string        `yaml:"url"`
 }
+
+func (r Redis) validate() []error {
+	errs := make([]error, 0)
+	if err := validateURL("redis.url", r.URL, "redis", "rediss", "unix"); err != nil {
+		errs = append(errs, err)
+	}
+	if r.Timeout <= 0 {
+		errs = append(errs, invalid("redis.timeout", "must be positive"))
+	}
+	return errs
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is a setting that differs between two configs.  The values of the
+// secrets are redacted.
+type Change struct {
+	Field string
+	From  string
+	To    string
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Field, c.From, c.To)
+}
+
+// Reload returns the copy of the config that has the settings that can be
+// changed at runtime taken from the next config:
+//
+//   - faucet
+//   - maintenance
+//   - reputation.github_orgs
+//   - cors
+//   - pow (except pow.enabled)
+//   - siwe (except siwe.enabled, siwe.eip1271 and siwe.mainnet_rpc_endpoint)
+//   - chain.id
+//   - server.auth_secret
+//   - admin.token
+//
+// Everything else requires a restart and is left as is.
+func (c *Config) Reload(next *Config) *Config {
+	res := *c
+
+	res.Admin.Token = next.Admin.Token
+	res.CORS = next.CORS
+	res.Chain.ID = next.Chain.ID
+	res.Faucet = next.Faucet
+	res.Maintenance = next.Maintenance
+	res.PoW = next.PoW
+	res.PoW.Enabled = c.PoW.Enabled
+	res.Reputation.GithubOrgs = next.Reputation.GithubOrgs
+	res.SIWE = next.SIWE
+	res.SIWE.EIP1271 = c.SIWE.EIP1271
+	res.SIWE.Enabled = c.SIWE.Enabled
+	res.SIWE.MainnetRPCEndpoint = c.SIWE.MainnetRPCEndpoint
+	res.Server.AuthSecret = next.Server.AuthSecret
+
+	return &res
+}
+
+// Diff lists the settings that differ between the two configs.
+func Diff(from, to *Config) []Change {
+	changes := make([]Change, 0)
+	diffStruct(
+		reflect.ValueOf(*from), reflect.ValueOf(*to),
+		reflect.ValueOf(from.Redacted()), reflect.ValueOf(to.Redacted()),
+		"", &changes,
+	)
+	return changes
+}
+
+// diffStruct compares the actual values, but reports the redacted ones.
+func diffStruct(from, to, fromShown, toShown reflect.Value, prefix string, changes *[]Change) {
+	t := from.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		field := prefix + name
+
+		f, n := from.Field(i), to.Field(i)
+		if f.Kind() == reflect.Struct {
+			diffStruct(f, n, fromShown.Field(i), toShown.Field(i), field+".", changes)
+			continue
+		}
+		if reflect.DeepEqual(f.Interface(), n.Interface()) {
+			continue
+		}
+
+		*changes = append(*changes, Change{
+			Field: field,
+			From:  fmt.Sprint(fromShown.Field(i).Interface()),
+			To:    fmt.Sprint(toShown.Field(i).Interface()),
+		})
+	}
+}
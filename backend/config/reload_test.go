@@ -0,0 +1,42 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReload(t *testing.T) {
+	current := &config.Config{
+		Faucet: config.Faucet{Interval: time.Minute, Payout: "1"},
+		PoW:    config.PoW{Difficulty: 18, Enabled: true},
+		SIWE:   config.SIWE{Domain: "faucet.example.com", Enabled: true},
+		Server: config.Server{AuthSecret: "old", ListenAddress: "0.0.0.0:8080"},
+	}
+	next := &config.Config{
+		Faucet: config.Faucet{Interval: time.Minute, Payout: "2"},
+		PoW:    config.PoW{Difficulty: 20},
+		SIWE:   config.SIWE{Domain: "faucet.example.org"},
+		Server: config.Server{AuthSecret: "new", ListenAddress: "0.0.0.0:9090"},
+	}
+
+	reloaded := current.Reload(next)
+	assert.Equal(t, "2", reloaded.Faucet.Payout)
+	assert.Equal(t, "new", reloaded.Server.AuthSecret)
+	assert.Equal(t, "0.0.0.0:8080", reloaded.Server.ListenAddress)
+	assert.Equal(t, "1", current.Faucet.Payout)
+
+	assert.Equal(t, []config.Change{
+		{Field: "faucet.payout", From: "1", To: "2"},
+		{Field: "pow.difficulty", From: "18", To: "20"},
+		{Field: "server.auth_secret", From: "<redacted>", To: "<redacted>"},
+		{Field: "siwe.domain", From: "faucet.example.com", To: "faucet.example.org"},
+	}, config.Diff(current, reloaded))
+	assert.Equal(t, []config.Change{
+		{Field: "pow.enabled", From: "true", To: "false"},
+		{Field: "server.listen_address", From: "0.0.0.0:8080", To: "0.0.0.0:9090"},
+		{Field: "siwe.enabled", From: "true", To: "false"},
+	}, config.Diff(reloaded, next))
+}
@@ -0,0 +1,45 @@
+package config
+
+import "time"
+
+type Reputation struct {
+	Action               string        `yaml:"action"`
+	CacheTTL             time.Duration `yaml:"cache_ttl"`
+	Enabled              bool          `yaml:"enabled"`
+	GithubAPIURL         string        `yaml:"github_api_url"`
+	GithubOrgs           []string      `yaml:"github_orgs"`
+	GithubToken          string        `yaml:"github_token"`
+	MinAccountAge        time.Duration `yaml:"min_account_age"`
+	MinFollowers         int           `yaml:"min_followers"`
+	MinPublicRepos       int           `yaml:"min_public_repos"`
+	ReducedPayoutPercent int64         `yaml:"reduced_payout_percent"`
+	Timeout              time.Duration `yaml:"timeout"`
+	TwitterAPIURL        string        `yaml:"twitter_api_url"`
+	TwitterBearerToken   string        `yaml:"twitter_bearer_token"`
+}
+
+func (r Reputation) validate() []error {
+	errs := make([]error, 0)
+	if !r.Enabled {
+		return errs
+	}
+	if r.Action != "deny" && r.Action != "reduce" {
+		errs = append(errs, invalid("reputation.action", "must be one of deny, reduce: %s", r.Action))
+	}
+	if r.ReducedPayoutPercent < 0 || r.ReducedPayoutPercent > 100 {
+		errs = append(errs, invalid("reputation.reduced_payout_percent", "must be between 0 and 100"))
+	}
+	if r.CacheTTL <= 0 {
+		errs = append(errs, invalid("reputation.cache_ttl", "must be positive"))
+	}
+	if r.Timeout <= 0 {
+		errs = append(errs, invalid("reputation.timeout", "must be positive"))
+	}
+	if r.MinFollowers < 0 {
+		errs = append(errs, invalid("reputation.min_followers", "must not be negative"))
+	}
+	if r.MinPublicRepos < 0 {
+		errs = append(errs, invalid("reputation.min_public_repos", "must not be negative"))
+	}
+	return errs
+}
@@ -1,8 +1,28 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 type RPC struct {
-	Endpoint string        `yaml:"endpoint"`
-	Timeout  time.Duration `yaml:"timeout"`
+	// Endpoints are tried in order, the first one that answers is used.
+	Endpoints []string      `yaml:"endpoints"`
+	Timeout   time.Duration `yaml:"timeout"`
+}
+
+func (r RPC) validate() []error {
+	errs := make([]error, 0)
+	if len(r.Endpoints) == 0 {
+		errs = append(errs, invalid("rpc.endpoints", "must not be empty"))
+	}
+	for i, endpoint := range r.Endpoints {
+		if err := validateURL(fmt.Sprintf("rpc.endpoints[%d]", i), endpoint, "http", "https", "ws", "wss"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.Timeout <= 0 {
+		errs = append(errs, invalid("rpc.timeout", "must be positive"))
+	}
+	return errs
 }
@@ -1,9 +1,46 @@
 package config
 
+import "time"
+
 type Server struct {
-	AuthSecret         string `yaml:"auth_secret"`
-	ListenAddress      string `yaml:"listen_address"`
-	MaxRequestBodySize int    `yaml:"max_request_body_size"`
-	ProxyCount         int    `yaml:"proxy_count"`
-	RedisAddress       string `yaml:"redis_address"`
+	AuthSecret         string        `yaml:"auth_secret"`
+	ClientCA           string        `yaml:"client_ca"`
+	IdempotencyWindow  time.Duration `yaml:"idempotency_window"`
+	ListenAddress      string        `yaml:"listen_address"`
+	MaxRequestBodySize int           `yaml:"max_request_body_size"`
+	ProxyCount         int           `yaml:"proxy_count"`
+	RedisAddress       string        `yaml:"redis_address"`
+	TLSCert            string        `yaml:"tls_cert"`
+	TLSKey             string        `yaml:"tls_key"`
+}
+
+func (s Server) TLSEnabled() bool {
+	return s.TLSCert != ""
+}
+
+func (s Server) validate() []error {
+	errs := make([]error, 0)
+	if s.AuthSecret == "" {
+		// hmac with an empty key would accept tokens signed with an empty key
+		errs = append(errs, invalid("server.auth_secret", "must not be empty"))
+	}
+	if s.IdempotencyWindow < 0 {
+		errs = append(errs, invalid("server.idempotency_window", "must not be negative"))
+	}
+	if err := validateHostPort("server.listen_address", s.ListenAddress); err != nil {
+		errs = append(errs, err)
+	}
+	if s.MaxRequestBodySize <= 0 {
+		errs = append(errs, invalid("server.max_request_body_size", "must be positive"))
+	}
+	if s.ProxyCount < 0 {
+		errs = append(errs, invalid("server.proxy_count", "must not be negative"))
+	}
+	if (s.TLSCert == "") != (s.TLSKey == "") {
+		errs = append(errs, invalid("server", "tls_cert and tls_key must be set together"))
+	}
+	if s.ClientCA != "" && s.TLSCert == "" {
+		errs = append(errs, invalid("server.client_ca", "requires tls_cert and tls_key"))
+	}
+	return errs
 }
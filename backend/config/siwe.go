@@ -0,0 +1,38 @@
+package config
+
+import "time"
+
+type SIWE struct {
+	Enabled            bool          `yaml:"enabled"`
+	Domain             string        `yaml:"domain"`
+	EIP1271            bool          `yaml:"eip1271"`
+	MainnetMinTxCount  uint64        `yaml:"mainnet_min_tx_count"`
+	MainnetRPCEndpoint string        `yaml:"mainnet_rpc_endpoint"`
+	NonceTTL           time.Duration `yaml:"nonce_ttl"`
+	SessionTTL         time.Duration `yaml:"session_ttl"`
+}
+
+func (s SIWE) validate() []error {
+	errs := make([]error, 0)
+	if !s.Enabled {
+		return errs
+	}
+	if s.Domain == "" {
+		errs = append(errs, invalid("siwe.domain", "must not be empty when siwe is enabled"))
+	}
+	if s.NonceTTL <= 0 {
+		errs = append(errs, invalid("siwe.nonce_ttl", "must be positive"))
+	}
+	if s.SessionTTL <= 0 {
+		errs = append(errs, invalid("siwe.session_ttl", "must be positive"))
+	}
+	if s.MainnetMinTxCount > 0 && s.MainnetRPCEndpoint == "" {
+		errs = append(errs, invalid("siwe.mainnet_rpc_endpoint", "must not be empty when siwe.mainnet_min_tx_count is set"))
+	}
+	if s.MainnetRPCEndpoint != "" {
+		if err := validateURL("siwe.mainnet_rpc_endpoint", s.MainnetRPCEndpoint, "http", "https", "ws", "wss"); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
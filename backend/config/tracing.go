@@ -0,0 +1,28 @@
+package config
+
+type Tracing struct {
+	Endpoint    string  `yaml:"endpoint"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+func (t Tracing) Enabled() bool {
+	return t.Endpoint != ""
+}
+
+func (t Tracing) validate() []error {
+	errs := make([]error, 0)
+	if !t.Enabled() {
+		return errs
+	}
+	if err := validateURL("tracing.endpoint", t.Endpoint, "http", "https"); err != nil {
+		errs = append(errs, err)
+	}
+	if t.SampleRatio < 0 || t.SampleRatio > 1 {
+		errs = append(errs, invalid("tracing.sample_ratio", "must be between 0 and 1: %v", t.SampleRatio))
+	}
+	if t.ServiceName == "" {
+		errs = append(errs, invalid("tracing.service_name", "must not be empty"))
+	}
+	return errs
+}
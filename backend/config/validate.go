@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+)
+
+var (
+	ErrConfigInvalid = errors.New("invalid configuration")
+)
+
+// Validate checks the whole configuration and reports all of the problems
+// found at once.
+func (c *Config) Validate() error {
+	errs := slices.Concat(
+		c.Admin.validate(),
+		c.Captcha.validate(),
+		c.Chain.validate(),
+		c.CORS.validate(),
+		c.Denylist.validate(),
+		c.Faucet.validate(c.Chain.TokenDecimals),
+		c.Health.validate(c.Chain.TokenDecimals),
+		c.Ledger.validate(),
+		c.Log.validate(),
+		c.Maintenance.validate(),
+		c.Metrics.validate(),
+		c.PoW.validate(),
+		c.Redis.validate(),
+		c.Reputation.validate(),
+		c.RPC.validate(),
+		c.Server.validate(),
+		c.SIWE.validate(),
+		c.Tracing.validate(),
+		c.Wallet.validate(),
+		c.Webhooks.validate(c.Chain.TokenDecimals),
+	)
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w:\n%w", ErrConfigInvalid, errors.Join(errs...))
+}
+
+func invalid(field, format string, a ...any) error {
+	return fmt.Errorf("%s: "+format, append([]any{field}, a...)...)
+}
+
+func validateURL(field, value string, schemes ...string) error {
+	if value == "" {
+		return invalid(field, "must not be empty")
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return invalid(field, "must be a valid url: %w", err)
+	}
+	if !slices.Contains(schemes, u.Scheme) {
+		return invalid(field, "must have one of the schemes %v: %s", schemes, value)
+	}
+	return nil
+}
+
+func validateHostPort(field, value string) error {
+	if _, _, err := net.SplitHostPort(value); err != nil {
+		return invalid(field, "must be host:port: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	cfg := &config.Config{
+		Chain:  config.Chain{Name: "testnet", TokenSymbol: "tEth"},
+		Faucet: config.Faucet{Interval: 15 * time.Minute, Payout: "1"},
+		Log:    config.Log{Level: "info", Mode: "prod"},
+		Redis:  config.Redis{URL: "redis://localhost:6379", Timeout: time.Second},
+		RPC:    config.RPC{Endpoints: []string{"http://localhost:8545"}, Timeout: time.Second},
+		Server: config.Server{AuthSecret: "secret", ListenAddress: "0.0.0.0:8080", MaxRequestBodySize: 1024},
+		Wallet: config.Wallet{PrivateKey: "91ab9a7e53c220e6210460b65a7a3bb2ca181412a8a7b43ff336b3df1737ce12"},
+	}
+	require.NoError(t, cfg.Validate())
+
+	cfg.CORS = config.CORS{AllowCredentials: true, AllowedOrigins: []string{"*"}}
+	cfg.Faucet.Interval = 0
+	cfg.Health.MinBalance = "lots"
+	cfg.Server.AuthSecret = ""
+	cfg.RPC.Endpoints = append(cfg.RPC.Endpoints, "ftp://localhost")
+	cfg.Webhooks = config.Webhooks{
+		RetryTimeout:  time.Minute,
+		Subscriptions: []config.Webhook{{Events: []string{"fund.sent", "fund.lost"}, Secret: "secret", URL: "https://example.com"}},
+		Timeout:       time.Second,
+	}
+	err := cfg.Validate()
+	require.ErrorIs(t, err, config.ErrConfigInvalid)
+	assert.ErrorContains(t, err, "cors.allowed_origins")
+	assert.ErrorContains(t, err, "faucet.interval")
+	assert.ErrorContains(t, err, "health.min_balance")
+	assert.ErrorContains(t, err, "server.auth_secret")
+	assert.ErrorContains(t, err, "rpc.endpoints[1]")
+	assert.ErrorContains(t, err, "webhooks.subscriptions[0].events")
+
+	cfg.Ledger.DSN = "host=localhost user=faucet password=hunter2 dbname=faucet"
+	cfg.Redis.URL = "redis://:hunter2@localhost:6379"
+	redacted := cfg.Redacted()
+	assert.Equal(t, "<redacted>", redacted.Wallet.PrivateKey)
+	assert.Equal(t, "host=localhost user=faucet password=<redacted> dbname=faucet", redacted.Ledger.DSN)
+	assert.Equal(t, "redis://:<redacted>@localhost:6379", redacted.Redis.URL)
+	assert.Equal(t, "tEth", redacted.Chain.TokenSymbol)
+	assert.Equal(t, "<redacted>", redacted.Webhooks.Subscriptions[0].Secret)
+	assert.NotEqual(t, "<redacted>", cfg.Wallet.PrivateKey)
+	assert.NotEqual(t, "<redacted>", cfg.Webhooks.Subscriptions[0].Secret)
+}
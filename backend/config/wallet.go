@@ -51,3 +51,18 @@ func (w Wallet) ECDSA() (*ecdsa.PrivateKey, error) {
 	}
 	return key.PrivateKey, nil
 }
+
+func (w Wallet) validate() []error {
+	errs := make([]error, 0)
+	switch {
+	case w.PrivateKey != "":
+		if _, err := crypto.HexToECDSA(strings.TrimPrefix(w.PrivateKey, "0x")); err != nil {
+			errs = append(errs, invalid("wallet.private_key", "must be a valid hex private key"))
+		}
+	case w.Keystore == "":
+		errs = append(errs, invalid("wallet", "%w", ErrMissingKeystoreOrPrivateKey))
+	case w.KeystorePassword == "":
+		errs = append(errs, invalid("wallet.keystore_password", "%w", ErrMissingKeystorePassword))
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/flashbots/eth-faucet/units"
+)
+
+var webhookEvents = []string{
+	"fund.confirmed",
+	"fund.failed",
+	"fund.requested",
+	"fund.sent",
+	"wallet.low_balance",
+}
+
+type Webhooks struct {
+	LowBalance    string        `yaml:"low_balance"`
+	RetryTimeout  time.Duration `yaml:"retry_timeout"`
+	Subscriptions []Webhook     `yaml:"subscriptions"`
+	Timeout       time.Duration `yaml:"timeout"`
+}
+
+// Webhook is the subscription of the url to the events (to all of them, if
+// none are listed).  The requests are signed with the secret.
+type Webhook struct {
+	Events []string `yaml:"events,omitempty"`
+	Secret string   `yaml:"secret"`
+	URL    string   `yaml:"url"`
+}
+
+func (w Webhooks) Enabled() bool {
+	return len(w.Subscriptions) > 0
+}
+
+// LowBalanceWei returns the balance below which the wallet.low_balance event
+// is sent, which defaults to the health's minimum balance.
+func (w Webhooks) LowBalanceWei(health Health, faucet Faucet, decimals uint) (*big.Int, error) {
+	if w.LowBalance == "" {
+		return health.MinBalanceWei(faucet, decimals)
+	}
+	return units.Parse(w.LowBalance, decimals)
+}
+
+// Wants tells whether the subscription is for the event.
+func (w Webhook) Wants(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+func (w Webhooks) validate(decimals uint) []error {
+	errs := make([]error, 0)
+	if !w.Enabled() {
+		return errs
+	}
+	if w.LowBalance != "" {
+		if _, err := units.Parse(w.LowBalance, decimals); err != nil {
+			errs = append(errs, invalid("webhooks.low_balance", "%w", err))
+		}
+	}
+	if w.RetryTimeout <= 0 {
+		errs = append(errs, invalid("webhooks.retry_timeout", "must be positive"))
+	}
+	if w.Timeout <= 0 {
+		errs = append(errs, invalid("webhooks.timeout", "must be positive"))
+	}
+	for i, s := range w.Subscriptions {
+		field := fmt.Sprintf("webhooks.subscriptions[%d]", i)
+		if err := validateURL(field+".url", s.URL, "http", "https"); err != nil {
+			errs = append(errs, err)
+		}
+		if s.Secret == "" {
+			errs = append(errs, invalid(field+".secret", "must not be empty"))
+		}
+		for _, event := range s.Events {
+			if !slices.Contains(webhookEvents, event) {
+				errs = append(errs, invalid(field+".events", "must be some of %v: %s", webhookEvents, event))
+			}
+		}
+	}
+	return errs
+}
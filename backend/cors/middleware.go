@@ -0,0 +1,111 @@
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/flashbots/eth-faucet/config"
+)
+
+// Middleware lets the browsers call the api from the allowed origins.  It
+// answers the preflight requests itself (so they never reach the handlers),
+// and adds the cors headers to the actual responses.
+//
+// The config is read on every request, so that the allowed origins could be
+// changed without a restart.
+func Middleware(cfg func() *config.CORS, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cors := cfg()
+		if !cors.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowOrigin, allowed := allowedOrigin(cors, origin)
+		preflight := r.Method == http.MethodOptions &&
+			r.Header.Get("Access-Control-Request-Method") != ""
+
+		if !preflight {
+			if allowed {
+				header.Set("Access-Control-Allow-Origin", allowOrigin)
+				if cors.AllowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+
+		method := r.Header.Get("Access-Control-Request-Method")
+		if !allowed || !slices.Contains(cors.Methods(), method) || !allowedHeaders(cors, r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Origin", allowOrigin)
+		header.Set("Access-Control-Allow-Methods", strings.Join(cors.Methods(), ", "))
+		header.Set("Access-Control-Allow-Headers", strings.Join(cors.Headers(), ", "))
+		if cors.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if cors.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowedOrigin returns the value of `Access-Control-Allow-Origin` for the
+// origin, if the latter is allowed.
+func allowedOrigin(cors *config.CORS, origin string) (string, bool) {
+	lower := strings.ToLower(origin)
+	for _, allowed := range cors.AllowedOrigins {
+		allowed = strings.TrimSuffix(strings.ToLower(allowed), "/")
+		switch {
+		case allowed == "*":
+			return "*", true
+		case allowed == lower:
+			return origin, true
+		case strings.Contains(allowed, "://*."):
+			scheme, domain, _ := strings.Cut(allowed, "://*")
+			if strings.HasPrefix(lower, scheme+"://") && strings.HasSuffix(lower, domain) &&
+				len(lower) > len(scheme+"://"+domain) {
+				return origin, true
+			}
+		}
+	}
+	return "", false
+}
+
+// allowedHeaders tells whether all of the headers the preflight asks for are
+// allowed.
+func allowedHeaders(cors *config.CORS, r *http.Request) bool {
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, requested := range strings.Split(value, ",") {
+			requested = strings.TrimSpace(requested)
+			if requested == "" {
+				continue
+			}
+			if !slices.ContainsFunc(cors.Headers(), func(allowed string) bool {
+				return strings.EqualFold(allowed, requested)
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
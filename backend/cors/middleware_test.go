@@ -0,0 +1,109 @@
+package cors_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/cors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	cfg := &config.CORS{
+		AllowCredentials: true,
+		AllowedOrigins:   []string{"https://docs.example.com", "https://*.dapps.example.com"},
+		MaxAge:           10 * time.Minute,
+	}
+	handler := cors.Middleware(func() *config.CORS { return cfg }, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				w.WriteHeader(http.StatusNotImplemented)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		},
+	))
+
+	serve := func(method, origin string, header map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/fund", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		for k, v := range header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	{ // preflight from an allowed origin is answered by the middleware
+		w := serve(http.MethodOptions, "https://widget.dapps.example.com", map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "content-type, authorization",
+		})
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://widget.dapps.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Authorization, Content-Type, Idempotency-Key", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	}
+
+	{ // preflight asking for a method or a header that is not allowed
+		w := serve(http.MethodOptions, "https://docs.example.com", map[string]string{
+			"Access-Control-Request-Method": "DELETE",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = serve(http.MethodOptions, "https://docs.example.com", map[string]string{
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "X-Custom",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	}
+
+	{ // preflight from an origin that is not allowed
+		for _, origin := range []string{"https://evil.example.com", "https://dapps.example.com", "http://docs.example.com"} {
+			w := serve(http.MethodOptions, origin, map[string]string{
+				"Access-Control-Request-Method": "POST",
+			})
+			assert.Equal(t, http.StatusForbidden, w.Code, origin)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"), origin)
+		}
+	}
+
+	{ // actual requests reach the handler either way
+		w := serve(http.MethodPost, "https://docs.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://docs.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = serve(http.MethodPost, "https://evil.example.com", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = serve(http.MethodOptions, "", nil)
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+	}
+
+	{ // any origin
+		cfg = &config.CORS{AllowedOrigins: []string{"*"}}
+		w := serve(http.MethodPost, "https://anywhere.example.org", nil)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	}
+
+	{ // disabled
+		cfg = &config.CORS{}
+		w := serve(http.MethodOptions, "https://docs.example.com", map[string]string{
+			"Access-Control-Request-Method": "POST",
+		})
+		assert.Equal(t, http.StatusNotImplemented, w.Code)
+		assert.Empty(t, w.Header().Values("Vary"))
+	}
+}
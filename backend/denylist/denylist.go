@@ -0,0 +1,218 @@
+package denylist
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrFileFailedToLoad = errors.New("failed to load denylist file")
+)
+
+// Denylist combines the entries loaded from the file with the ones added at
+// runtime.  The latter are kept in redis, so that they survive restarts and
+// are shared by all replicas.
+type Denylist struct {
+	backoffParams *backoff.Parameters
+	file          string
+	fileEntries   atomic.Pointer[[]Entry]
+	patterns      sync.Map // textual form -> parsed runtime pattern
+	prefix        string
+	redis         *redis.Client
+}
+
+func New(cfg *config.Config, _redis *redis.Client) (*Denylist, error) {
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	d := &Denylist{
+		backoffParams: backoffParams,
+		file:          cfg.Denylist.File,
+		prefix:        redisutils.Prefix(&cfg.Redis),
+		redis:         _redis,
+	}
+	d.fileEntries.Store(&[]Entry{})
+	if d.file != "" {
+		if _, err := d.LoadFile(); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// LoadFile (re-)reads the entries from the file (one per line, `#` starts a
+// comment) and reports how many were loaded.  The previously loaded entries
+// stay in effect if the file fails to load.
+func (d *Denylist) LoadFile() (int, error) {
+	f, err := os.Open(d.file)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFileFailedToLoad, err)
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		entry, err := ParseEntry(text)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s:%d: %w", ErrFileFailedToLoad, d.file, line, err)
+		}
+		entry.Source = SourceFile
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrFileFailedToLoad, err)
+	}
+
+	d.fileEntries.Store(&entries)
+	return len(entries), nil
+}
+
+func (d *Denylist) Add(ctx context.Context, entry Entry) error {
+	return backoff.Backoff(ctx, d.backoffParams, func(ctx context.Context) error {
+		return d.redis.SAdd(ctx, d.key(entry), entry.String()).Err()
+	})
+}
+
+// Remove deletes the entry added at runtime and reports whether it was there.
+func (d *Denylist) Remove(ctx context.Context, entry Entry) (bool, error) {
+	var removed int64
+	err := backoff.Backoff(ctx, d.backoffParams, func(ctx context.Context) (_err error) {
+		removed, _err = d.redis.SRem(ctx, d.key(entry), entry.String()).Result()
+		return
+	})
+	if err != nil {
+		return false, err
+	}
+	return removed > 0, nil
+}
+
+func (d *Denylist) List(ctx context.Context) ([]Entry, error) {
+	var exact, patterns []string
+	err := backoff.Backoff(ctx, d.backoffParams, func(ctx context.Context) error {
+		pipe := d.redis.Pipeline()
+		exactCmd := pipe.SMembers(ctx, d.keyExact())
+		patternsCmd := pipe.SMembers(ctx, d.keyPatterns())
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		exact, patterns = exactCmd.Val(), patternsCmd.Val()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := slices.Concat(exact, patterns)
+	slices.Sort(members)
+
+	entries := slices.Clone(*d.fileEntries.Load())
+	for _, m := range members {
+		if entry, err := ParseEntry(m); err == nil {
+			entry.Source = SourceRuntime
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Match returns the entry that denies the request (nil if there is none).
+func (d *Denylist) Match(ctx context.Context, request Request) (*Entry, error) {
+	for _, entry := range *d.fileEntries.Load() {
+		if entry.matches(request) {
+			return &entry, nil
+		}
+	}
+
+	candidates := []Entry{
+		{Kind: KindIdentity, Value: identity(request.Provider, request.Username)},
+	}
+	if address, err := ParseEntry(request.Address); err == nil && address.Kind == KindAddress {
+		candidates = append(candidates, address)
+	}
+	members := make([]interface{}, 0, len(candidates))
+	for _, c := range candidates {
+		members = append(members, c.String())
+	}
+
+	var (
+		found    []bool
+		patterns []string
+	)
+	err := backoff.Backoff(ctx, d.backoffParams, func(ctx context.Context) error {
+		pipe := d.redis.Pipeline()
+		foundCmd := pipe.SMIsMember(ctx, d.keyExact(), members...)
+		patternsCmd := pipe.SMembers(ctx, d.keyPatterns())
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		found, patterns = foundCmd.Val(), patternsCmd.Val()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, f := range found {
+		if f {
+			entry := candidates[i]
+			entry.Source = SourceRuntime
+			return &entry, nil
+		}
+	}
+	for _, p := range patterns {
+		entry, ok := d.pattern(p)
+		if ok && entry.matches(request) {
+			return &entry, nil
+		}
+	}
+	return nil, nil
+}
+
+// pattern parses the pattern once and then reuses it (as compiling regular
+// expressions on every request is wasteful).
+func (d *Denylist) pattern(s string) (Entry, bool) {
+	if entry, ok := d.patterns.Load(s); ok {
+		return entry.(Entry), true
+	}
+	entry, err := ParseEntry(s)
+	if err != nil {
+		return Entry{}, false
+	}
+	entry.Source = SourceRuntime
+	d.patterns.Store(s, entry)
+	return entry, true
+}
+
+func (d *Denylist) key(entry Entry) string {
+	if entry.exact() {
+		return d.keyExact()
+	}
+	return d.keyPatterns()
+}
+
+func (d *Denylist) keyExact() string {
+	return d.prefix + "denylist"
+}
+
+func (d *Denylist) keyPatterns() string {
+	return d.prefix + "denylist:patterns"
+}
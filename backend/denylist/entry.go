@@ -0,0 +1,139 @@
+package denylist
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	KindAddress  = "address"
+	KindCIDR     = "cidr"
+	KindIdentity = "identity"
+	KindRegex    = "regex"
+)
+
+const (
+	SourceFile    = "file"
+	SourceRuntime = "runtime"
+)
+
+var (
+	ErrEntryMalformed = errors.New("denylist entry is malformed")
+)
+
+// Entry is a denied address (e.g. "0x0000000000000000000000000000000000000001"),
+// identity (e.g. "github:username"), ip range (e.g. "192.0.2.0/24"), or a
+// regular expression for the usernames (e.g. "/^bot-[0-9]+$/", or
+// "github:/^bot-[0-9]+$/" for the usernames of a single provider).
+type Entry struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Source string `json:"source,omitempty"`
+
+	prefix   netip.Prefix
+	provider string
+	regex    *regexp.Regexp
+}
+
+// Request is what the entries are matched against.
+type Request struct {
+	Address  string
+	IP       string
+	Provider string
+	Username string
+}
+
+// ParseEntry parses the entry from its textual form, normalising it so that
+// the equal entries are spelled the same way.
+func ParseEntry(s string) (Entry, error) {
+	s = strings.TrimSpace(s)
+
+	if common.IsHexAddress(s) {
+		return Entry{Kind: KindAddress, Value: common.HexToAddress(s).Hex()}, nil
+	}
+
+	if provider, pattern, ok := cutRegex(s); ok {
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			return Entry{}, fmt.Errorf("%w: %w", ErrEntryMalformed, err)
+		}
+		value := "/" + pattern + "/"
+		if provider != "" {
+			value = provider + ":" + value
+		}
+		return Entry{Kind: KindRegex, Value: value, provider: provider, regex: regex}, nil
+	}
+
+	if prefix, ok := parsePrefix(s); ok {
+		return Entry{Kind: KindCIDR, Value: prefix.String(), prefix: prefix}, nil
+	}
+
+	provider, username, found := strings.Cut(s, ":")
+	if found && provider != "" && username != "" {
+		return Entry{Kind: KindIdentity, Value: identity(provider, username)}, nil
+	}
+
+	return Entry{}, fmt.Errorf("%w: %s", ErrEntryMalformed, s)
+}
+
+func (e Entry) String() string {
+	return e.Value
+}
+
+// exact tells whether the entry can be looked up by its value (as opposed to
+// the patterns that have to be tried one by one).
+func (e Entry) exact() bool {
+	return e.Kind == KindAddress || e.Kind == KindIdentity
+}
+
+func (e Entry) matches(request Request) bool {
+	switch e.Kind {
+	case KindAddress:
+		return common.IsHexAddress(request.Address) &&
+			common.HexToAddress(request.Address).Hex() == e.Value
+	case KindCIDR:
+		ip, err := netip.ParseAddr(request.IP)
+		return err == nil && e.prefix.Contains(ip.Unmap())
+	case KindIdentity:
+		return identity(request.Provider, request.Username) == e.Value
+	case KindRegex:
+		return (e.provider == "" || e.provider == strings.ToLower(request.Provider)) &&
+			e.regex.MatchString(request.Username)
+	}
+	return false
+}
+
+// cutRegex recognises "/pattern/" and "provider:/pattern/".
+func cutRegex(s string) (provider, pattern string, ok bool) {
+	if !strings.HasSuffix(s, "/") {
+		return "", "", false
+	}
+	if p, rest, found := strings.Cut(s, ":/"); found && !strings.Contains(p, "/") {
+		provider, s = strings.ToLower(p), "/"+rest
+	}
+	if len(s) < 3 || s[0] != '/' {
+		return "", "", false
+	}
+	return provider, s[1 : len(s)-1], true
+}
+
+// parsePrefix accepts the cidr ranges as well as the single ip addresses.
+func parsePrefix(s string) (netip.Prefix, bool) {
+	if prefix, err := netip.ParsePrefix(s); err == nil {
+		return prefix.Masked(), true
+	}
+	if ip, err := netip.ParseAddr(s); err == nil {
+		ip = ip.Unmap()
+		return netip.PrefixFrom(ip, ip.BitLen()), true
+	}
+	return netip.Prefix{}, false
+}
+
+func identity(provider, username string) string {
+	return strings.ToLower(provider) + ":" + strings.ToLower(username)
+}
@@ -0,0 +1,57 @@
+package denylist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEntry(t *testing.T) {
+	for s, expected := range map[string]Entry{
+		"0xbe69d72ca5f88acba033a063df5dbe43a4148de0": {Kind: KindAddress, Value: "0xBE69d72ca5f88aCba033a063dF5DBe43a4148De0"},
+		" GitHub:Alice ":        {Kind: KindIdentity, Value: "github:alice"},
+		"192.0.2.77/24":         {Kind: KindCIDR, Value: "192.0.2.0/24"},
+		"192.0.2.1":             {Kind: KindCIDR, Value: "192.0.2.1/32"},
+		"2001:db8::1":           {Kind: KindCIDR, Value: "2001:db8::1/128"},
+		"/^bot-[0-9]+$/":        {Kind: KindRegex, Value: "/^bot-[0-9]+$/"},
+		"GitHub:/^bot-[0-9]+$/": {Kind: KindRegex, Value: "github:/^bot-[0-9]+$/"},
+	} {
+		entry, err := ParseEntry(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected.Kind, entry.Kind, s)
+		assert.Equal(t, expected.Value, entry.Value, s)
+	}
+
+	for _, s := range []string{"", "alice", "github:", "/[/", "10.0.0.0/33"} {
+		_, err := ParseEntry(s)
+		assert.ErrorIs(t, err, ErrEntryMalformed, s)
+	}
+}
+
+func TestEntryMatches(t *testing.T) {
+	request := Request{
+		Address:  "0x00000000000000000000000000000000000000ff",
+		IP:       "192.0.2.77",
+		Provider: "github",
+		Username: "Bot-42",
+	}
+
+	for s, expected := range map[string]bool{
+		"0x00000000000000000000000000000000000000FF": true,
+		"0x0000000000000000000000000000000000000001": false,
+		"github:bot-42":              true,
+		"twitter:bot-42":             false,
+		"192.0.2.0/24":               true,
+		"::ffff:192.0.2.0/120":       false,
+		"198.51.100.0/24":            false,
+		"/^(?i)bot-[0-9]+$/":         true,
+		"/^bot-[0-9]+$/":             false,
+		"twitter:/^(?i)bot-[0-9]+$/": false,
+		"github:/^(?i)bot-[0-9]+$/":  true,
+	} {
+		entry, err := ParseEntry(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, entry.matches(request), s)
+	}
+}
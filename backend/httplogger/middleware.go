@@ -1,32 +1,74 @@
 package httplogger
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/tracing"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+type contextKey string
+
+const requestIDContextKey contextKey = "requestID"
+
+// RequestID returns the id the middleware assigned to the request (the same
+// one is logged and sent back in the `X-Request-Id` header).
+func RequestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey).(string)
+	return id
+}
+
 func Middleware(logger *zap.Logger, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Generate request ID (`base64` to shorten its string representation)
 		_uuid := [16]byte(uuid.New())
 		httpRequestID := base64.RawStdEncoding.EncodeToString(_uuid[:])
+		w.Header().Set("X-Request-Id", httpRequestID)
 
-		l := logger.With(
+		// Continue the trace of the caller (if any).  The span is renamed
+		// after the route once the mux has matched one (the paths would
+		// blow up the cardinality of the span names).
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.EscapedPath()),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("http.request.id", httpRequestID),
+			),
+		)
+		defer span.End()
+		base := logger
+		if span.SpanContext().HasTraceID() {
+			base = logger.With(zap.String("traceID", span.SpanContext().TraceID().String()))
+		}
+
+		l := base.With(
 			zap.String("httpRequestID", httpRequestID),
 			zap.String("logType", "activity"),
 		)
-		r = logutils.RequestWithLogger(r, l)
+		ctx = context.WithValue(ctx, requestIDContextKey, httpRequestID)
+		r = logutils.RequestWithLogger(r.WithContext(ctx), l)
 
 		// Handle panics
 		defer func() {
 			if msg := recover(); msg != nil {
 				w.WriteHeader(http.StatusInternalServerError)
+				span.SetStatus(codes.Error, fmt.Sprint(msg))
 				var method, url string
 				if r != nil {
 					method = r.Method
@@ -44,9 +86,18 @@ func Middleware(logger *zap.Logger, next http.Handler) http.Handler {
 		wrapped := wrapResponseWriter(w)
 		next.ServeHTTP(wrapped, r)
 
+		if route := routeOf(r); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(wrapped.Status()))
+		if wrapped.Status() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.Status()))
+		}
+
 		// Passing request stats both in-message (for the human reader)
 		// as well as inside the structured log (for the machine parser)
-		logger.Info(fmt.Sprintf("%s: %s %s %d", r.URL.Scheme, r.Method, r.URL.EscapedPath(), wrapped.Status()),
+		base.Info(fmt.Sprintf("%s: %s %s %d", r.URL.Scheme, r.Method, r.URL.EscapedPath(), wrapped.Status()),
 			zap.Int("durationMs", int(time.Since(start).Milliseconds())),
 			zap.Int("status", wrapped.Status()),
 			zap.String("httpRequestID", httpRequestID),
@@ -57,3 +108,12 @@ func Middleware(logger *zap.Logger, next http.Handler) http.Handler {
 		)
 	})
 }
+
+// routeOf returns the pattern the mux matched the request with (without the
+// method, if the pattern has one).
+func routeOf(r *http.Request) string {
+	if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+		return path
+	}
+	return r.Pattern
+}
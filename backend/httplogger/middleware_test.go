@@ -0,0 +1,77 @@
+package httplogger_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/zap"
+)
+
+func TestMiddlewareTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/fund/{address}", func(w http.ResponseWriter, r *http.Request) {
+		attempts := 0
+		err := backoff.Backoff(r.Context(), &backoff.Parameters{BaseTimeout: 10 * time.Millisecond}, func(_ context.Context) error {
+			attempts++
+			if attempts == 1 {
+				return backoff.Retryable(errors.New("flaky"))
+			}
+			return nil
+		})
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	handler := httplogger.Middleware(zap.NewNop(), mux)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/fund/0xa1", nil)
+	req.Header.Set("traceparent", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+	require.Equal(t, http.StatusAccepted, res.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	// the attempts end before the request does
+	first, second, request := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "POST /api/fund/{address}", request.Name())
+	assert.Equal(t, trace.SpanKindServer, request.SpanKind())
+	assert.Equal(t, "0af7651916cd43dd8448eb211c80319c", request.SpanContext().TraceID().String())
+	assert.Equal(t, "b7ad6b7169203331", request.Parent().SpanID().String())
+	assert.True(t, request.Parent().IsRemote())
+
+	for _, attempt := range []sdktrace.ReadOnlySpan{first, second} {
+		assert.True(t, strings.HasPrefix(attempt.Name(), "httplogger_test.TestMiddlewareTracing"), attempt.Name())
+		assert.Equal(t, request.SpanContext().SpanID(), attempt.Parent().SpanID())
+	}
+	assert.Equal(t, codes.Error, first.Status().Code)
+	assert.Equal(t, codes.Unset, second.Status().Code)
+}
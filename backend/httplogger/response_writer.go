@@ -30,3 +30,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 	rw.wroteHeader = true
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer (e.g. to
+// flush the event streams).
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
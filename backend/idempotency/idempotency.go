@@ -0,0 +1,241 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	// lockTTL is for how long the key stays claimed by the request that is
+	// in progress unless extended (so that the one that crashed does not
+	// block the retries for the whole window)
+	lockTTL = time.Minute
+
+	// lockExtendInterval is how often the claim is extended while the
+	// request is in progress
+	lockExtendInterval = lockTTL / 3
+
+	// pollInterval is how often the duplicates check whether the first
+	// request is done
+	pollInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrKeyInProgress = errors.New("request with the same idempotency key is in progress")
+	ErrKeyMismatch   = errors.New("idempotency key is already used for a different request")
+	ErrLockLost      = errors.New("idempotency key is no longer claimed by the request")
+)
+
+// The scripts act on the key only while it holds the pending marker of the
+// claim (KEYS[1] is the key, ARGV[1] is the marker).
+var (
+	scriptComplete = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3]) and 1
+end
+return 0
+`)
+
+	scriptExtend = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+	scriptRelease = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+)
+
+// Response is the stored response to the first request with the key.
+type Response struct {
+	Fingerprint string            `json:"fingerprint"`
+	Pending     bool              `json:"pending,omitempty"`
+	Token       string            `json:"token,omitempty"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// Store keeps the responses in redis, so that the retries hitting other
+// replicas are answered the same.
+type Store struct {
+	backoffParams *backoff.Parameters
+	prefix        string
+	redis         *redis.Client
+	window        time.Duration
+}
+
+func New(cfg *config.Config, _redis *redis.Client) (*Store, error) {
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	return &Store{
+		backoffParams: backoffParams,
+		prefix:        redisutils.Prefix(&cfg.Redis),
+		redis:         _redis,
+		window:        cfg.Server.IdempotencyWindow,
+	}, nil
+}
+
+// Lock is the claim of the key by the request in progress.  It is extended
+// until the request is completed or released.
+type Lock struct {
+	key     string
+	pending string
+	store   *Store
+	stop    chan struct{}
+}
+
+// Begin claims the key (scoped by the identity) for the request with the
+// fingerprint.  It returns the lock if the request is the first one (it is
+// then to be completed or released), or the response to the first one
+// otherwise.  The duplicates wait for the first request to finish, for as
+// long as the context allows.
+func (s *Store) Begin(ctx context.Context, scope, key, fingerprint string) (*Lock, *Response, error) {
+	redisKey := s.key(scope, key)
+	pending, err := json.Marshal(&Response{
+		Fingerprint: fingerprint,
+		Pending:     true,
+		Token:       uuid.Must(uuid.NewRandom()).String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for {
+		var (
+			claimed bool
+			stored  string
+		)
+		err := backoff.Backoff(ctx, s.backoffParams, func(ctx context.Context) (_err error) {
+			claimed, _err = s.redis.SetNX(ctx, redisKey, pending, lockTTL).Result()
+			if _err != nil || claimed {
+				return
+			}
+			stored, _err = s.redis.Get(ctx, redisKey).Result()
+			if errors.Is(_err, redis.Nil) {
+				// expired in the meantime, will try claiming again
+				_err = nil
+			}
+			return
+		})
+		if err != nil && ctx.Err() != nil {
+			return nil, nil, ErrKeyInProgress
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if claimed {
+			lock := &Lock{
+				key:     redisKey,
+				pending: string(pending),
+				store:   s,
+				stop:    make(chan struct{}),
+			}
+			go lock.extend()
+			return lock, nil, nil
+		}
+
+		if stored != "" {
+			res := &Response{}
+			if err := json.Unmarshal([]byte(stored), res); err != nil {
+				return nil, nil, err
+			}
+			if res.Fingerprint != fingerprint {
+				return nil, nil, ErrKeyMismatch
+			}
+			if !res.Pending {
+				return nil, res, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ErrKeyInProgress
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Complete stores the response for the retries within the window.
+func (l *Lock) Complete(ctx context.Context, res *Response) error {
+	close(l.stop)
+
+	stored, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return l.run(ctx, scriptComplete, stored, l.store.window.Milliseconds())
+}
+
+// Release gives the key up, so that the retry is processed anew.
+func (l *Lock) Release(ctx context.Context) error {
+	close(l.stop)
+
+	return l.run(ctx, scriptRelease)
+}
+
+// extend keeps the key claimed for as long as the request is in progress
+// (e.g. while the payout waits for the rpc), so that the retries do not get
+// to pay out again.
+func (l *Lock) extend() {
+	ticker := time.NewTicker(lockExtendInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), lockExtendInterval)
+			err := l.run(ctx, scriptExtend, lockTTL.Milliseconds())
+			cancel()
+			if errors.Is(err, ErrLockLost) {
+				return
+			}
+			if err != nil {
+				zap.L().Warn("Failed to extend idempotency key claim", zap.Error(err))
+			}
+		}
+	}
+}
+
+// run runs the script on the key, unless the key is no longer claimed.
+func (l *Lock) run(ctx context.Context, script *redis.Script, args ...any) error {
+	var done int64
+	err := backoff.Backoff(ctx, l.store.backoffParams, func(ctx context.Context) (_err error) {
+		done, _err = script.Run(ctx, l.store.redis, []string{l.key}, append([]any{l.pending}, args...)...).Int64()
+		return
+	})
+	if err != nil {
+		return err
+	}
+	if done == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// key hashes the client's key, so that its length and contents do not
+// matter to redis.
+func (s *Store) key(scope, key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return s.prefix + "idempotency:" + scope + ":" + hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,112 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	s, err := New(&config.Config{
+		Redis:  config.Redis{Timeout: time.Second},
+		Server: config.Server{IdempotencyWindow: time.Hour},
+	}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, err)
+	return s, mr
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newStore(t)
+
+	lock, res, err := s.Begin(ctx, "github:alice", "k1", "a1")
+	require.NoError(t, err)
+	require.NotNil(t, lock, "the first request is to be processed")
+	require.Nil(t, res)
+
+	// the duplicate waits for the first request
+	replayed := make(chan *Response)
+	go func() {
+		_, res, err := s.Begin(ctx, "github:alice", "k1", "a1")
+		assert.NoError(t, err)
+		replayed <- res
+	}()
+
+	_, _, err = s.Begin(ctx, "github:alice", "k1", "a2")
+	assert.ErrorIs(t, err, ErrKeyMismatch)
+
+	waitCtx, cancel := context.WithTimeout(ctx, 3*pollInterval)
+	_, _, err = s.Begin(waitCtx, "github:alice", "k1", "a1")
+	cancel()
+	assert.ErrorIs(t, err, ErrKeyInProgress)
+
+	require.NoError(t, lock.Complete(ctx, &Response{
+		Fingerprint: "a1",
+		Status:      200,
+		Body:        []byte(`{"message":"TxHash: 0x01"}`),
+	}))
+	res = <-replayed
+	require.NotNil(t, res)
+	assert.Equal(t, 200, res.Status)
+	assert.Equal(t, `{"message":"TxHash: 0x01"}`, string(res.Body))
+
+	// the keys are scoped by the identity
+	lock, _, err = s.Begin(ctx, "github:bob", "k1", "a1")
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+
+	// the released key is processed anew
+	require.NoError(t, lock.Release(ctx))
+	lock, _, err = s.Begin(ctx, "github:bob", "k1", "a2")
+	require.NoError(t, err)
+	assert.NotNil(t, lock)
+}
+
+func TestLockLost(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+
+	lock, _, err := s.Begin(ctx, "github:alice", "k1", "a1")
+	require.NoError(t, err)
+
+	// the claim expired, and the retry claimed the key in the meantime
+	mr.FastForward(lockTTL)
+	retry, _, err := s.Begin(ctx, "github:alice", "k1", "a1")
+	require.NoError(t, err)
+	require.NotNil(t, retry)
+
+	// the response does not overwrite the claim of the retry
+	assert.ErrorIs(t, lock.Complete(ctx, &Response{Fingerprint: "a1", Status: 200}), ErrLockLost)
+	require.NoError(t, retry.Release(ctx))
+
+	lock, _, err = s.Begin(ctx, "github:alice", "k2", "a1")
+	require.NoError(t, err)
+	mr.FastForward(lockTTL)
+	assert.ErrorIs(t, lock.Release(ctx), ErrLockLost)
+}
+
+func TestLockExtend(t *testing.T) {
+	ctx := context.Background()
+	s, mr := newStore(t)
+
+	lock, _, err := s.Begin(ctx, "github:alice", "k1", "a1")
+	require.NoError(t, err)
+	key := s.key("github:alice", "k1")
+
+	mr.FastForward(lockTTL / 2)
+	require.NoError(t, lock.run(ctx, scriptExtend, lockTTL.Milliseconds()))
+	assert.Equal(t, lockTTL, mr.TTL(key))
+
+	require.NoError(t, lock.Complete(ctx, &Response{Fingerprint: "a1", Status: 200}))
+	assert.Equal(t, time.Hour, mr.TTL(key))
+
+	// the completed response is not extended as a claim
+	assert.ErrorIs(t, lock.run(ctx, scriptExtend, lockTTL.Milliseconds()), ErrLockLost)
+}
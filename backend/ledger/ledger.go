@@ -0,0 +1,86 @@
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+)
+
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// The outcomes of the fund attempts.
+const (
+	StatusConfirmed   = "confirmed"    // transaction is confirmed
+	StatusDenied      = "denied"       // on the denylist
+	StatusFailed      = "failed"       // failed to send the transaction
+	StatusIneligible  = "ineligible"   // insufficient reputation
+	StatusInvalid     = "invalid"      // failed captcha, pow, amount etc
+	StatusRateLimited = "rate_limited" // came too early
+	StatusReplaced    = "replaced"     // transaction's nonce is taken by another one
+	StatusReverted    = "reverted"     // transaction is included, but reverted
+	StatusSent        = "sent"         // transaction is sent
+)
+
+var (
+	ErrDriverUnknown = errors.New("unknown ledger driver")
+)
+
+// Entry is a single fund attempt.
+type Entry struct {
+	ID        int64
+	AttemptID string // unique, so that recording the attempt again is harmless
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Provider string
+	Username string
+	APIKeyID string
+	Address  string
+	IP       string
+	Amount   *big.Int // nil if not yet determined
+
+	Status string
+	Error  string
+
+	TxHash string
+	Sender string
+	Nonce  *uint64
+	SentAt *time.Time
+}
+
+// Ledger records the fund attempts, so that they could be accounted for and
+// investigated later on.
+type Ledger interface {
+	// Record stores the attempt and sets its id.
+	Record(ctx context.Context, entry *Entry) error
+
+	// Update stores the changed status (and error) of the recorded attempt.
+	Update(ctx context.Context, entry *Entry) error
+
+	Close() error
+}
+
+// New returns the ledger for the configured driver, or nil if the ledger is
+// switched off.
+func New(cfg *config.Config) (Ledger, error) {
+	if !cfg.Ledger.Enabled() {
+		return nil, nil
+	}
+
+	switch driver := strings.ToLower(cfg.Ledger.Driver); driver {
+	case DriverPostgres:
+		return newSQL(&cfg.Ledger, postgres)
+	case DriverSQLite:
+		return newSQL(&cfg.Ledger, sqlite)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrDriverUnknown, cfg.Ledger.Driver)
+	}
+}
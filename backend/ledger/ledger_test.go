@@ -0,0 +1,87 @@
+package ledger_test
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/ledger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLite(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Ledger = config.Ledger{
+		Driver:  "sqlite",
+		DSN:     filepath.Join(t.TempDir(), "ledger.db"),
+		Timeout: time.Second,
+	}
+
+	l, err := ledger.New(cfg)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	nonce := uint64(42)
+	sentAt := time.Now().UTC()
+	sent := &ledger.Entry{
+		AttemptID: "a1",
+		Provider:  "github",
+		Username:  "alice",
+		Address:   "0x0000000000000000000000000000000000000001",
+		IP:        "192.0.2.1",
+		Amount:    big.NewInt(1_000_000_000_000_000_000),
+		Status:    ledger.StatusSent,
+		TxHash:    "0x01",
+		Nonce:     &nonce,
+		SentAt:    &sentAt,
+	}
+	require.NoError(t, l.Record(ctx, sent))
+	// recording the same attempt again (e.g. when retried) is harmless
+	require.NoError(t, l.Record(ctx, sent))
+	limited := &ledger.Entry{
+		Provider: "github",
+		Username: "alice",
+		Address:  "0x0000000000000000000000000000000000000001",
+		Status:   ledger.StatusRateLimited,
+	}
+	require.NoError(t, l.Record(ctx, limited))
+	assert.Equal(t, int64(1), sent.ID)
+	assert.Equal(t, int64(3), limited.ID)
+
+	sent.Status = ledger.StatusConfirmed
+	require.NoError(t, l.Update(ctx, sent))
+	require.NoError(t, l.Close())
+
+	// re-opening does not re-apply the migrations
+	l, err = ledger.New(cfg)
+	require.NoError(t, err)
+	require.NoError(t, l.Close())
+
+	db, err := sql.Open("sqlite", cfg.Ledger.DSN)
+	require.NoError(t, err)
+	defer db.Close()
+
+	var (
+		migrations  int
+		payouts     int
+		amount      sql.NullString
+		status      string
+		storedNonce sql.NullInt64
+	)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&migrations))
+	assert.Equal(t, 2, migrations)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM payouts").Scan(&payouts))
+	assert.Equal(t, 2, payouts)
+	require.NoError(t, db.QueryRow("SELECT amount_wei, status, nonce FROM payouts WHERE id = 1").Scan(&amount, &status, &storedNonce))
+	assert.Equal(t, "1000000000000000000", amount.String)
+	assert.Equal(t, ledger.StatusConfirmed, status)
+	assert.Equal(t, int64(42), storedNonce.Int64)
+	require.NoError(t, db.QueryRow("SELECT amount_wei, status, nonce FROM payouts WHERE id = 3").Scan(&amount, &status, &storedNonce))
+	assert.False(t, amount.Valid)
+	assert.False(t, storedNonce.Valid)
+}
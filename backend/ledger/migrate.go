@@ -0,0 +1,135 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMigrationMalformed = errors.New("migration is malformed")
+)
+
+// The migrations are numbered sql files (e.g. `0001_create_payouts.sql`), one
+// directory per dialect.  Once released, a migration must not be changed:
+// the schema evolves by adding the new ones.
+//
+//go:embed migrations
+var migrations embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// migrate applies the migrations that were not yet applied to the database,
+// each within its own transaction.
+func migrate(ctx context.Context, db *sql.DB, d *dialect) error {
+	pending, err := loadMigrations(d)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if d.lock != "" {
+		if _, err := conn.ExecContext(ctx, d.lock); err != nil {
+			return err
+		}
+		defer conn.ExecContext(context.Background(), d.unlock) //nolint:errcheck
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	var current int
+	err = conn.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&current)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range pending {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(ctx, conn, d, m); err != nil {
+			return fmt.Errorf("%s: %w", m.name, err)
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.Conn, d *dialect, m migration) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() //nolint:errcheck
+
+	// the statements are run one by one, as not all drivers accept several
+	// of them at once (the migrations must not have `;` inside the literals)
+	for _, stmt := range strings.Split(m.sql, ";") {
+		if strings.TrimSpace(stmt) == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+
+	query := "INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"
+	if d.numbered {
+		query = "INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)"
+	}
+	if _, err := tx.ExecContext(ctx, query, m.version, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func loadMigrations(d *dialect) ([]migration, error) {
+	dir := path.Join("migrations", d.name)
+	files, err := migrations.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]migration, 0, len(files))
+	for _, f := range files {
+		prefix, _, found := strings.Cut(f.Name(), "_")
+		version, err := strconv.Atoi(prefix)
+		if !found || err != nil || version <= 0 || !strings.HasSuffix(f.Name(), ".sql") {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationMalformed, f.Name())
+		}
+		b, err := migrations.ReadFile(path.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, migration{version: version, name: f.Name(), sql: string(b)})
+	}
+
+	slices.SortFunc(res, func(a, b migration) int {
+		return a.version - b.version
+	})
+	return res, nil
+}
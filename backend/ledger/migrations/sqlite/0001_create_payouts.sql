@@ -0,0 +1,25 @@
+CREATE TABLE payouts (
+    id                INTEGER PRIMARY KEY AUTOINCREMENT,
+    created_at        TIMESTAMP NOT NULL,
+    updated_at        TIMESTAMP NOT NULL,
+
+    identity_provider TEXT NOT NULL,
+    identity_username TEXT NOT NULL,
+    api_key_id        TEXT NOT NULL DEFAULT '',
+    address           TEXT NOT NULL,
+    ip                TEXT NOT NULL DEFAULT '',
+    amount_wei        TEXT,
+
+    status            TEXT NOT NULL,
+    error             TEXT NOT NULL DEFAULT '',
+
+    tx_hash           TEXT NOT NULL DEFAULT '',
+    sender            TEXT NOT NULL DEFAULT '',
+    nonce             INTEGER,
+    sent_at           TIMESTAMP
+);
+
+CREATE INDEX payouts_address ON payouts (address);
+CREATE INDEX payouts_created_at ON payouts (created_at);
+CREATE INDEX payouts_identity ON payouts (identity_provider, identity_username);
+CREATE INDEX payouts_tx_hash ON payouts (tx_hash);
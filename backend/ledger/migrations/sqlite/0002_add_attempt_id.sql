@@ -0,0 +1,3 @@
+ALTER TABLE payouts ADD COLUMN attempt_id TEXT;
+
+CREATE UNIQUE INDEX payouts_attempt_id ON payouts (attempt_id);
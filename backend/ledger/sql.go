@@ -0,0 +1,200 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers "pgx" driver
+	_ "modernc.org/sqlite"             // registers "sqlite" driver
+)
+
+var (
+	ErrDatabaseFailedToOpen    = errors.New("failed to open ledger database")
+	ErrDatabaseFailedToMigrate = errors.New("failed to migrate ledger database")
+	ErrEntryFailedToRecord     = errors.New("failed to record ledger entry")
+	ErrEntryFailedToUpdate     = errors.New("failed to update ledger entry")
+)
+
+// dialect captures the differences between the databases.
+type dialect struct {
+	name   string
+	driver string
+
+	// setup runs on open
+	setup []string
+
+	// lock and unlock serialise the migrations run by several replicas
+	lock   string
+	unlock string
+
+	// numbered tells whether the placeholders are $1, $2, ... (instead of ?)
+	numbered bool
+}
+
+var postgres = &dialect{
+	name:     DriverPostgres,
+	driver:   "pgx",
+	lock:     "SELECT pg_advisory_lock(7106224358441934918)",
+	unlock:   "SELECT pg_advisory_unlock(7106224358441934918)",
+	numbered: true,
+}
+
+var sqlite = &dialect{
+	name:   DriverSQLite,
+	driver: "sqlite",
+	setup: []string{
+		"PRAGMA busy_timeout = 5000",
+		"PRAGMA journal_mode = WAL",
+	},
+}
+
+type sqlLedger struct {
+	backoffParams *backoff.Parameters
+	db            *sql.DB
+	dialect       *dialect
+}
+
+func newSQL(cfg *config.Ledger, d *dialect) (*sqlLedger, error) {
+	dsn := cfg.DSN
+	if d == sqlite && !strings.Contains(dsn, "_time_format=") {
+		// store the timestamps in the format sqlite's date functions accept
+		// (instead of go's time.String)
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		dsn += separator + "_time_format=sqlite"
+	}
+
+	db, err := sql.Open(d.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDatabaseFailedToOpen, err)
+	}
+	if d == sqlite {
+		// sqlite allows a single writer at a time anyway
+		db.SetMaxOpenConns(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, stmt := range d.setup {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%w: %w", ErrDatabaseFailedToOpen, err)
+		}
+	}
+	if err := migrate(ctx, db, d); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %w", ErrDatabaseFailedToMigrate, err)
+	}
+
+	return &sqlLedger{
+		backoffParams: &backoff.Parameters{
+			BaseTimeout: cfg.Timeout,
+		},
+		db:      db,
+		dialect: d,
+	}, nil
+}
+
+func (l *sqlLedger) Record(ctx context.Context, entry *Entry) error {
+	now := time.Now().UTC()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+	entry.UpdatedAt = now
+
+	var amount sql.NullString
+	if entry.Amount != nil {
+		amount = sql.NullString{String: entry.Amount.String(), Valid: true}
+	}
+	var nonce sql.NullInt64
+	if entry.Nonce != nil {
+		nonce = sql.NullInt64{Int64: int64(*entry.Nonce), Valid: true}
+	}
+	var sentAt sql.NullTime
+	if entry.SentAt != nil {
+		sentAt = sql.NullTime{Time: entry.SentAt.UTC(), Valid: true}
+	}
+
+	var attemptID sql.NullString
+	if entry.AttemptID != "" {
+		attemptID = sql.NullString{String: entry.AttemptID, Valid: true}
+	}
+
+	// the insert is retried, and the retry might follow the one that did
+	// succeed (but timed out), hence the conflict is a no-op that still
+	// returns the id
+	query := l.rebind(`
+		INSERT INTO payouts (
+			attempt_id, created_at, updated_at,
+			identity_provider, identity_username, api_key_id, address, ip, amount_wei,
+			status, error,
+			tx_hash, sender, nonce, sent_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (attempt_id) DO UPDATE SET attempt_id = excluded.attempt_id
+		RETURNING id`,
+	)
+
+	err := backoff.Backoff(ctx, l.backoffParams, func(ctx context.Context) error {
+		return l.db.QueryRowContext(ctx, query,
+			attemptID, entry.CreatedAt.UTC(), entry.UpdatedAt,
+			entry.Provider, entry.Username, entry.APIKeyID, entry.Address, entry.IP, amount,
+			entry.Status, entry.Error,
+			entry.TxHash, entry.Sender, nonce, sentAt,
+		).Scan(&entry.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEntryFailedToRecord, err)
+	}
+	return nil
+}
+
+func (l *sqlLedger) Update(ctx context.Context, entry *Entry) error {
+	entry.UpdatedAt = time.Now().UTC()
+
+	query := l.rebind(`
+		UPDATE payouts SET status = ?, error = ?, updated_at = ?
+		WHERE id = ?`,
+	)
+
+	err := backoff.Backoff(ctx, l.backoffParams, func(ctx context.Context) error {
+		_, err := l.db.ExecContext(ctx, query, entry.Status, entry.Error, entry.UpdatedAt, entry.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEntryFailedToUpdate, err)
+	}
+	return nil
+}
+
+func (l *sqlLedger) Close() error {
+	return l.db.Close()
+}
+
+// rebind rewrites the ? placeholders into the ones of the dialect.
+func (l *sqlLedger) rebind(query string) string {
+	if !l.dialect.numbered {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, c := range query {
+		if c != '?' {
+			b.WriteRune(c)
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String()
+}
@@ -49,3 +49,24 @@ func NewLogger(cfg *config.Log) (
 
 	return l, nil
 }
+
+// NewFileLogger returns the logger that appends json-encoded entries to the
+// file (e.g. for an audit trail that is kept apart from the server log).
+func NewFileLogger(path string) (
+	*zap.Logger, error,
+) {
+	config := zap.NewProductionConfig()
+	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	config.OutputPaths = []string{path}
+	config.ErrorOutputPaths = []string{"stderr"}
+	config.Sampling = nil
+
+	l, err := config.Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w",
+			ErrLoggerFailedToBuild, err,
+		)
+	}
+
+	return l, nil
+}
@@ -0,0 +1,128 @@
+package maintenance
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+)
+
+const (
+	ReasonMaintenance = "maintenance"
+	ReasonSchedule    = "schedule"
+)
+
+const (
+	defaultMessageMaintenance = "The faucet is under maintenance"
+	defaultMessageSchedule    = "The faucet is closed at the moment"
+)
+
+// Status tells whether the payouts are stopped, and why.
+type Status struct {
+	Active   bool
+	Reason   string
+	Message  string
+	ResumeAt time.Time // zero when unknown
+}
+
+// Maintenance decides when the faucet is closed: either because the
+// maintenance is switched on (in the config, or at runtime), or because it is
+// outside of the scheduled opening windows.
+type Maintenance struct {
+	config   atomic.Pointer[state]
+	override atomic.Pointer[state]
+}
+
+type state struct {
+	message  string
+	resumeAt time.Time
+	schedule *schedule
+	enabled  bool
+}
+
+func New(cfg *config.Config) (*Maintenance, error) {
+	m := &Maintenance{}
+	s, err := newState(&cfg.Maintenance)
+	if err != nil {
+		return nil, err
+	}
+	m.config.Store(s)
+	return m, nil
+}
+
+// Reload applies the changed maintenance settings.  The config is expected to
+// be validated; the settings that fail to parse are not applied.
+func (m *Maintenance) Reload(cfg *config.Config) {
+	if s, err := newState(&cfg.Maintenance); err == nil {
+		m.config.Store(s)
+	}
+}
+
+// Enable switches the maintenance on at runtime (regardless of the config).
+func (m *Maintenance) Enable(message string, resumeAt time.Time) {
+	m.override.Store(&state{
+		enabled:  true,
+		message:  message,
+		resumeAt: resumeAt,
+	})
+}
+
+// Disable switches off the maintenance that was enabled at runtime, and
+// reports whether it was on.
+func (m *Maintenance) Disable() bool {
+	return m.override.Swap(nil) != nil
+}
+
+func (m *Maintenance) Status(now time.Time) Status {
+	configured := m.config.Load()
+
+	for _, s := range []*state{m.override.Load(), configured} {
+		if s == nil || !s.enabled {
+			continue
+		}
+		status := Status{
+			Active:  true,
+			Reason:  ReasonMaintenance,
+			Message: defaultMessageMaintenance,
+		}
+		if s.message != "" {
+			status.Message = s.message
+		}
+		if s.resumeAt.After(now) {
+			status.ResumeAt = s.resumeAt
+		}
+		return status
+	}
+
+	if open, opensAt := configured.schedule.open(now); !open {
+		status := Status{
+			Active:   true,
+			Reason:   ReasonSchedule,
+			Message:  defaultMessageSchedule,
+			ResumeAt: opensAt,
+		}
+		if configured.schedule.message != "" {
+			status.Message = configured.schedule.message
+		}
+		return status
+	}
+
+	return Status{}
+}
+
+func newState(cfg *config.Maintenance) (*state, error) {
+	resumeAt, err := cfg.ResumeTime()
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := newSchedule(&cfg.Schedule)
+	if err != nil {
+		return nil, err
+	}
+	return &state{
+		enabled:  cfg.Enabled,
+		message:  cfg.Message,
+		resumeAt: resumeAt,
+		schedule: schedule,
+	}, nil
+}
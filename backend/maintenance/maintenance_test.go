@@ -0,0 +1,72 @@
+package maintenance_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/maintenance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatus(t *testing.T) {
+	cfg := &config.Config{}
+	m, err := maintenance.New(cfg)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	assert.False(t, m.Status(now).Active)
+
+	resumeAt := now.Add(time.Hour)
+	m.Enable("Upgrading the chain", resumeAt)
+	assert.Equal(t, maintenance.Status{
+		Active:   true,
+		Reason:   maintenance.ReasonMaintenance,
+		Message:  "Upgrading the chain",
+		ResumeAt: resumeAt,
+	}, m.Status(now))
+	assert.True(t, m.Disable())
+	assert.False(t, m.Disable())
+	assert.False(t, m.Status(now).Active)
+
+	cfg.Maintenance.Enabled = true
+	m.Reload(cfg)
+	assert.Equal(t, maintenance.Status{
+		Active:  true,
+		Reason:  maintenance.ReasonMaintenance,
+		Message: "The faucet is under maintenance",
+	}, m.Status(now))
+}
+
+func TestSchedule(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Maintenance.Schedule = config.Schedule{
+		Timezone: "Europe/Berlin",
+		Windows: []config.Window{
+			{Days: []string{"mon", "tue", "wed", "thu", "fri"}, From: "09:00", To: "18:00"},
+			{Days: []string{"sat"}, From: "22:00", To: "02:00"},
+		},
+	}
+	m, err := maintenance.New(cfg)
+	require.NoError(t, err)
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, berlin) // 2024-03-01 is friday
+	}
+
+	assert.False(t, m.Status(at(1, 12, 0)).Active)
+
+	status := m.Status(at(1, 18, 0))
+	assert.True(t, status.Active)
+	assert.Equal(t, maintenance.ReasonSchedule, status.Reason)
+	assert.Equal(t, at(2, 22, 0), status.ResumeAt)
+
+	assert.False(t, m.Status(at(3, 1, 59)).Active)
+	assert.Equal(t, at(4, 9, 0), m.Status(at(3, 2, 0)).ResumeAt)
+
+	// switching the maintenance on takes precedence over the schedule
+	m.Enable("", time.Time{})
+	assert.Equal(t, maintenance.ReasonMaintenance, m.Status(at(1, 18, 0)).Reason)
+}
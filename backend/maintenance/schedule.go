@@ -0,0 +1,86 @@
+package maintenance
+
+import (
+	"slices"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+)
+
+type schedule struct {
+	location *time.Location
+	message  string
+	windows  []window
+}
+
+type window struct {
+	days []time.Weekday // nil for every day
+	from int            // minutes since midnight
+	to   int
+}
+
+func newSchedule(cfg *config.Schedule) (*schedule, error) {
+	location, err := cfg.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]window, 0, len(cfg.Windows))
+	for _, w := range cfg.Windows {
+		days, err := w.Weekdays()
+		if err != nil {
+			return nil, err
+		}
+		from, to, err := w.Times()
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, window{
+			days: days,
+			from: int(from.Minutes()),
+			to:   int(to.Minutes()),
+		})
+	}
+
+	return &schedule{
+		location: location,
+		message:  cfg.Message,
+		windows:  windows,
+	}, nil
+}
+
+// open tells whether the time falls into one of the windows, and if not then
+// when the next one opens.
+func (s *schedule) open(now time.Time) (bool, time.Time) {
+	if len(s.windows) == 0 {
+		return true, time.Time{}
+	}
+
+	now = now.In(s.location)
+	year, month, day := now.Date()
+
+	var opensAt time.Time
+	// the window that opened yesterday might still be open, and the next one
+	// opens within a week at most
+	for d := day - 1; d <= day+7; d++ {
+		for _, w := range s.windows {
+			start := time.Date(year, month, d, 0, w.from, 0, 0, s.location)
+			if w.days != nil && !slices.Contains(w.days, start.Weekday()) {
+				continue
+			}
+			end := time.Date(year, month, d, 0, w.to, 0, 0, s.location)
+			if w.to < w.from {
+				end = time.Date(year, month, d+1, 0, w.to, 0, 0, s.location)
+			}
+
+			if !now.Before(start) && now.Before(end) {
+				return true, time.Time{}
+			}
+			if start.After(now) && (opensAt.IsZero() || start.Before(opensAt)) {
+				opensAt = start
+			}
+		}
+	}
+
+	return false, opensAt
+}
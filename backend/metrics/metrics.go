@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "faucet"
+
+// The outcomes of the fund requests that are rejected before they make it
+// into the ledger (the rest are accounted for by the ledger's statuses).
+const (
+	FundOutcomeMaintenance  = "maintenance"
+	FundOutcomeUnauthorised = "unauthorised"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+	factory  = promauto.With(registry)
+)
+
+var (
+	backoffRetries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backoff_retries_total",
+		Help:      "Retries of the backoff-wrapped operations (by the function that ran the backoff).",
+	}, []string{"operation"})
+
+	fundRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fund_requests_total",
+		Help:      "Fund requests by their outcome.",
+	}, []string{"outcome"})
+
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status.",
+	}, []string{"route", "method", "status"})
+
+	payoutWei = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "payout_wei_total",
+		Help:      "Wei sent out by the faucet.",
+	})
+
+	redisDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Latency of the redis commands.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	rpcDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_request_duration_seconds",
+		Help:      "Latency of the rpc requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	webhookDeliveries = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Webhook deliveries by event and outcome (delivered or failed, after the retries).",
+	}, []string{"event", "outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+func RecordBackoffRetry(operation string) {
+	backoffRetries.WithLabelValues(operation).Inc()
+}
+
+func RecordFund(outcome string) {
+	fundRequests.WithLabelValues(outcome).Inc()
+}
+
+func RecordHTTPRequest(route, method string, status int) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+}
+
+func RecordPayout(amount *big.Int) {
+	wei, _ := new(big.Float).SetInt(amount).Float64()
+	payoutWei.Add(wei)
+}
+
+func RecordWebhookDelivery(event, outcome string) {
+	webhookDeliveries.WithLabelValues(event, outcome).Inc()
+}
+
+// ObserveRPC records the latency of the rpc request that started at `start`
+// (meant to be deferred).
+func ObserveRPC(method string, start time.Time) {
+	rpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+)
+
+// Middleware counts the requests served by the mux.  The requests are labeled
+// with the mux's pattern (instead of the path) to keep the cardinality in
+// check.
+func Middleware(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unmatched"
+		if _, pattern := mux.Handler(r); pattern != "" {
+			route = pattern
+		}
+
+		wrapped := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(wrapped, r)
+
+		RecordHTTPRequest(route, r.Method, wrapped.status)
+	})
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook records the latency of the redis commands (the pipelines are
+// recorded as a whole).
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		redisDuration.WithLabelValues(cmd.Name()).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		redisDuration.WithLabelValues("pipeline").Observe(time.Since(start).Seconds())
+		return err
+	}
+}
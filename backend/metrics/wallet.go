@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Wallet is the faucet's wallet, as seen locally and on chain.
+type Wallet interface {
+	Balance(ctx context.Context) (*big.Int, error)
+	Nonce() uint64
+	PendingNonce(ctx context.Context) (uint64, error)
+}
+
+var (
+	walletBalanceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "wallet", "balance_wei"),
+		"Balance of the faucet's wallet.", nil, nil,
+	)
+	walletNonceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "wallet", "nonce"),
+		"Nonce the next transaction will be sent with.", nil, nil,
+	)
+	walletPendingNonceDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "wallet", "pending_nonce"),
+		"Pending nonce of the faucet's wallet on chain.", nil, nil,
+	)
+)
+
+// walletCollector queries the rpc on scrape, so that the values are never
+// stale.  The values that fail to be read are omitted.
+type walletCollector struct {
+	timeout time.Duration
+	wallet  Wallet
+}
+
+// RegisterWallet exports the balance and the nonces of the wallet.
+func RegisterWallet(wallet Wallet, timeout time.Duration) {
+	registry.MustRegister(&walletCollector{
+		timeout: timeout,
+		wallet:  wallet,
+	})
+}
+
+func (c *walletCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- walletBalanceDesc
+	ch <- walletNonceDesc
+	ch <- walletPendingNonceDesc
+}
+
+func (c *walletCollector) Collect(ch chan<- prometheus.Metric) {
+	l := zap.L()
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	ch <- prometheus.MustNewConstMetric(walletNonceDesc, prometheus.GaugeValue, float64(c.wallet.Nonce()))
+
+	if balance, err := c.wallet.Balance(ctx); err == nil {
+		wei, _ := new(big.Float).SetInt(balance).Float64()
+		ch <- prometheus.MustNewConstMetric(walletBalanceDesc, prometheus.GaugeValue, wei)
+	} else {
+		l.Warn("Failed to get wallet balance for metrics", zap.Error(err))
+	}
+
+	if nonce, err := c.wallet.PendingNonce(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(walletPendingNonceDesc, prometheus.GaugeValue, float64(nonce))
+	} else {
+		l.Warn("Failed to get wallet pending nonce for metrics", zap.Error(err))
+	}
+}
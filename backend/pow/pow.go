@@ -0,0 +1,202 @@
+package pow
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	challengeVersion = "v1"
+)
+
+var (
+	ErrChallengeExpired        = errors.New("pow challenge is expired")
+	ErrChallengeMalformed      = errors.New("pow challenge is malformed")
+	ErrChallengeMissing        = errors.New("pow challenge is missing")
+	ErrChallengeSignature      = errors.New("pow challenge has invalid signature")
+	ErrChallengeAlreadyUsed    = errors.New("pow challenge is already used")
+	ErrSolutionDoesNotMeetGoal = errors.New("pow solution does not meet the difficulty")
+)
+
+// Challenge is what the client must solve: find such a nonce that
+// sha256(challenge || nonce) has at least `difficulty` leading zero bits.
+type Challenge struct {
+	Challenge  string    `json:"challenge"`
+	Difficulty int       `json:"difficulty"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type PoW struct {
+	backoffParams *backoff.Parameters
+	cfg           atomic.Pointer[config.PoW]
+	prefix        string
+	redis         *redis.Client
+}
+
+func New(cfg *config.Config, _redis *redis.Client) (*PoW, error) {
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	p := &PoW{
+		backoffParams: backoffParams,
+		prefix:        redisutils.Prefix(&cfg.Redis),
+		redis:         _redis,
+	}
+	p.Reload(cfg)
+
+	return p, nil
+}
+
+// Reload applies the changed settings.  The challenges signed with the
+// previous secret become invalid, the ones issued with the previous
+// difficulty or ttl stay as they were issued.
+func (p *PoW) Reload(cfg *config.Config) {
+	pow := cfg.PoW
+	if pow.Secret == "" {
+		pow.Secret = cfg.Server.AuthSecret
+	}
+	p.cfg.Store(&pow)
+}
+
+// Issue creates a challenge bound to the client's ip.  Its difficulty grows
+// with the overall volume of the recently issued challenges, and even more so
+// with the volume of the ones issued to the same ip.
+func (p *PoW) Issue(ctx context.Context, ip string) (*Challenge, error) {
+	cfg := p.cfg.Load()
+
+	total, err := p.increment(ctx, "pow-volume", cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+	perIP, err := p.increment(ctx, "pow-volume:ip:"+ip, cfg.Window)
+	if err != nil {
+		return nil, err
+	}
+
+	difficulty := cfg.Difficulty +
+		bits.Len64(uint64(total))/2 +
+		bits.Len64(uint64(perIP-1))
+	if cfg.DifficultyMax > 0 {
+		difficulty = min(difficulty, cfg.DifficultyMax)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(cfg.TTL).Truncate(time.Second)
+
+	payload := strings.Join([]string{
+		challengeVersion,
+		hex.EncodeToString(id),
+		strconv.Itoa(difficulty),
+		strconv.FormatInt(expiresAt.Unix(), 10),
+	}, ".")
+
+	return &Challenge{
+		Challenge:  payload + "." + sign(cfg.Secret, payload, ip),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt.UTC(),
+	}, nil
+}
+
+// Verify checks the solution of the challenge that was issued to the ip, and
+// marks the challenge as used.
+func (p *PoW) Verify(ctx context.Context, challenge, nonce, ip string) error {
+	if challenge == "" {
+		return ErrChallengeMissing
+	}
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 5 || parts[0] != challengeVersion {
+		return ErrChallengeMalformed
+	}
+	payload := strings.Join(parts[:4], ".")
+	if !hmac.Equal([]byte(parts[4]), []byte(sign(p.cfg.Load().Secret, payload, ip))) {
+		return ErrChallengeSignature
+	}
+
+	difficulty, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChallengeMalformed, err)
+	}
+	expiresAtUnix, err := strconv.ParseInt(parts[3], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrChallengeMalformed, err)
+	}
+	expiresAt := time.Unix(expiresAtUnix, 0)
+	if time.Now().After(expiresAt) {
+		return ErrChallengeExpired
+	}
+
+	hash := sha256.Sum256([]byte(challenge + nonce))
+	if leadingZeroBits(hash[:]) < difficulty {
+		return ErrSolutionDoesNotMeetGoal
+	}
+
+	var fresh bool
+	err = backoff.Backoff(ctx, p.backoffParams, func(ctx context.Context) (_err error) {
+		fresh, _err = p.redis.SetNX(ctx, p.prefix+"pow-used:"+parts[1], nonce, time.Until(expiresAt)+time.Second).Result()
+		return
+	})
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrChallengeAlreadyUsed
+	}
+
+	return nil
+}
+
+func (p *PoW) increment(ctx context.Context, key string, window time.Duration) (int64, error) {
+	key = p.prefix + key
+
+	var count *redis.IntCmd
+	err := backoff.Backoff(ctx, p.backoffParams, func(ctx context.Context) (_err error) {
+		_, _err = p.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			count = pipe.Incr(ctx, key)
+			pipe.ExpireNX(ctx, key, window)
+			return nil
+		})
+		return
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
+
+func sign(secret, payload, ip string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload + "|" + ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func leadingZeroBits(b []byte) int {
+	count := 0
+	for _, x := range b {
+		if x != 0 {
+			return count + bits.LeadingZeros8(x)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,138 @@
+package pow_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"math/bits"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/pow"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig(t *testing.T) *config.Config {
+	return &config.Config{
+		PoW: config.PoW{
+			Difficulty:    4,
+			DifficultyMax: 6,
+			Enabled:       true,
+			Secret:        "secret",
+			TTL:           time.Minute,
+			Window:        time.Hour,
+		},
+		Redis: config.Redis{Timeout: time.Second},
+	}
+}
+
+func newRedis(t *testing.T) *redis.Client {
+	return redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+}
+
+// solve finds the nonce that meets (or, when not wanted, misses) the
+// challenge's difficulty.
+func solve(c *pow.Challenge, meets bool) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		hash := sha256.Sum256([]byte(c.Challenge + nonce))
+		zeros := 0
+		for _, b := range hash {
+			zeros += bits.LeadingZeros8(b)
+			if b != 0 {
+				break
+			}
+		}
+		if (zeros >= c.Difficulty) == meets {
+			return nonce
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := pow.New(newConfig(t), newRedis(t))
+	require.NoError(t, err)
+
+	c, err := p.Issue(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, c.Difficulty)
+
+	assert.ErrorIs(t, p.Verify(ctx, c.Challenge, solve(c, false), "10.0.0.1"), pow.ErrSolutionDoesNotMeetGoal)
+	assert.ErrorIs(t, p.Verify(ctx, c.Challenge, solve(c, true), "10.0.0.2"), pow.ErrChallengeSignature)
+	assert.NoError(t, p.Verify(ctx, c.Challenge, solve(c, true), "10.0.0.1"))
+	assert.ErrorIs(t, p.Verify(ctx, c.Challenge, solve(c, true), "10.0.0.1"), pow.ErrChallengeAlreadyUsed)
+
+	// the difficulty is signed
+	parts := strings.Split(c.Challenge, ".")
+	parts[2] = "0"
+	assert.ErrorIs(t, p.Verify(ctx, strings.Join(parts, "."), "0", "10.0.0.1"), pow.ErrChallengeSignature)
+
+	assert.ErrorIs(t, p.Verify(ctx, "", "0", "10.0.0.1"), pow.ErrChallengeMissing)
+	assert.ErrorIs(t, p.Verify(ctx, "v1.abc", "0", "10.0.0.1"), pow.ErrChallengeMalformed)
+}
+
+func TestVerifyExpired(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := newConfig(t)
+	cfg.PoW.TTL = -time.Minute
+	p, err := pow.New(cfg, newRedis(t))
+	require.NoError(t, err)
+
+	c, err := p.Issue(ctx, "10.0.0.1")
+	require.NoError(t, err)
+	assert.ErrorIs(t, p.Verify(ctx, c.Challenge, solve(c, true), "10.0.0.1"), pow.ErrChallengeExpired)
+}
+
+func TestIssueDifficulty(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := newConfig(t)
+	p, err := pow.New(cfg, newRedis(t))
+	require.NoError(t, err)
+
+	for _, expected := range []struct {
+		ip         string
+		difficulty int
+	}{
+		{"10.0.0.1", 4}, // base
+		{"10.0.0.1", 6}, // +1 for the volume, +1 for the same ip
+		{"10.0.0.2", 5}, // +1 for the volume
+		{"10.0.0.1", 6}, // capped
+	} {
+		c, err := p.Issue(ctx, expected.ip)
+		require.NoError(t, err)
+		assert.Equal(t, expected.difficulty, c.Difficulty, expected.ip)
+	}
+
+	// the reloaded settings apply to the next challenges
+	reloaded := *cfg
+	reloaded.PoW.Difficulty, reloaded.PoW.DifficultyMax = 8, 8
+	p.Reload(&reloaded)
+	c, err := p.Issue(ctx, "10.0.0.3")
+	require.NoError(t, err)
+	assert.Equal(t, 8, c.Difficulty)
+}
+
+func TestReloadSecret(t *testing.T) {
+	ctx := context.Background()
+
+	cfg := newConfig(t)
+	p, err := pow.New(cfg, newRedis(t))
+	require.NoError(t, err)
+
+	c, err := p.Issue(ctx, "10.0.0.1")
+	require.NoError(t, err)
+
+	reloaded := *cfg
+	reloaded.PoW.Secret = "rotated"
+	p.Reload(&reloaded)
+	assert.ErrorIs(t, p.Verify(ctx, c.Challenge, solve(c, true), "10.0.0.1"), pow.ErrChallengeSignature)
+}
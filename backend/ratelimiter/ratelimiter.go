@@ -3,12 +3,23 @@ package ratelimiter
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/flashbots/eth-faucet/backoff"
 	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/flashbots/eth-faucet/tracing"
 	"github.com/redis/go-redis/v9"
-	"go.uber.org/zap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrChargedTotalMalformed = errors.New("charged total is malformed")
 )
 
 type RateLimiter struct {
@@ -17,47 +28,27 @@ type RateLimiter struct {
 	redis         *redis.Client
 }
 
-func New(cfg *config.Config) (*RateLimiter, error) {
-	l := zap.L()
-
+func New(cfg *config.Config, _redis *redis.Client) (*RateLimiter, error) {
 	backoffParams := &backoff.Parameters{
 		BaseTimeout: cfg.Redis.Timeout,
 	}
 
-	redisOptions, err := redis.ParseURL(cfg.Redis.URL)
-	if err != nil {
-		return nil, err
-	}
-	_redis := redis.NewClient(redisOptions)
-
-	l.Info("Connecting to redis...", zap.String("redis_url", cfg.Redis.URL))
-	err = backoff.Backoff(context.Background(), backoffParams, func(ctx context.Context) (_err error) {
-		status := _redis.Ping(ctx)
-		_err = status.Err()
-		if _err != nil {
-			l.Warn("Failed to connect to redis", zap.Error(_err))
-		}
-		return
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	prefix := ""
-	if cfg.Redis.Namespace != "" {
-		prefix = cfg.Redis.Namespace + ":"
-	}
-
 	return &RateLimiter{
 		backoffParams: backoffParams,
-		prefix:        prefix,
+		prefix:        redisutils.Prefix(&cfg.Redis),
 		redis:         _redis,
 	}, nil
 }
 
+// Ping checks that redis is reachable.
+func (rl *RateLimiter) Ping(ctx context.Context) error {
+	return rl.redis.Ping(ctx).Err()
+}
+
 func (rl *RateLimiter) Register(ctx context.Context, key string, expiration time.Duration) error {
+	ctx, span := rl.startSpan(ctx, "ratelimiter.Register", key)
 	key = rl.prefix + key
-	return backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
+	err := backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
 		_, _err = rl.redis.Set(
 			ctx,
 			key,
@@ -66,27 +57,217 @@ func (rl *RateLimiter) Register(ctx context.Context, key string, expiration time
 		).Result()
 		return
 	})
+	tracing.End(span, err)
+	return err
 }
 
-func (rl *RateLimiter) IsRegistered(ctx context.Context, key string) (time.Time, error) {
+func (rl *RateLimiter) IsRegistered(ctx context.Context, key string) (_ time.Time, err error) {
+	ctx, span := rl.startSpan(ctx, "ratelimiter.IsRegistered", key)
+	defer func() { tracing.End(span, err) }()
 	key = rl.prefix + key
 
 	var res string
-	err := backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
+	err = backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
 		res, _err = rl.redis.Get(
 			ctx,
 			key,
 		).Result()
+		if errors.Is(_err, redis.Nil) {
+			// not registered (handled here, so that the miss does not show
+			// up as a failed attempt)
+			res, _err = "", nil
+		}
 		return
 	})
-
-	redisNil := redis.Nil
-	if errors.As(err, &redisNil) {
-		return time.Time{}, nil
-	}
 	if err != nil {
 		return time.Time{}, err
 	}
+	if res == "" {
+		return time.Time{}, nil
+	}
 
 	return time.Parse(time.RFC3339, res)
 }
+
+// Increment bumps the counter stored under the key and returns its new value
+// together with the time left until the counter resets.  The window starts
+// with the first increment.
+func (rl *RateLimiter) Increment(ctx context.Context, key string, window time.Duration) (_ int64, _ time.Duration, err error) {
+	ctx, span := rl.startSpan(ctx, "ratelimiter.Increment", key)
+	defer func() { tracing.End(span, err) }()
+	key = rl.prefix + key
+
+	var (
+		count *redis.IntCmd
+		ttl   *redis.DurationCmd
+	)
+	err = backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
+		_, _err = rl.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			count = pipe.Incr(ctx, key)
+			pipe.ExpireNX(ctx, key, window)
+			ttl = pipe.TTL(ctx, key)
+			return nil
+		})
+		return
+	})
+	if err != nil {
+		return 0, time.Duration(0), err
+	}
+
+	return count.Val(), ttl.Val(), nil
+}
+
+// Charge adds the amount to the total accumulated under the key, unless the
+// new total would exceed the limit.  In that case nothing is charged, and the
+// time left until the total resets is returned instead.  The window starts
+// with the first charge.
+func (rl *RateLimiter) Charge(
+	ctx context.Context,
+	key string,
+	amount *big.Int,
+	limit *big.Int,
+	window time.Duration,
+) (_ time.Duration, err error) {
+	ctx, span := rl.startSpan(ctx, "ratelimiter.Charge", key)
+	defer func() { tracing.End(span, err) }()
+	key = rl.prefix + key
+
+	var wait time.Duration
+	err = backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) error {
+		wait = time.Duration(0)
+		err := rl.redis.Watch(ctx, func(tx *redis.Tx) error {
+			total := big.NewInt(0)
+			res, err := tx.Get(ctx, key).Result()
+			switch {
+			case errors.Is(err, redis.Nil):
+				// nothing charged yet
+			case err != nil:
+				return err
+			default:
+				if _, ok := total.SetString(res, 10); !ok {
+					return fmt.Errorf("%w: %s", ErrChargedTotalMalformed, res)
+				}
+			}
+
+			ttl, err := tx.PTTL(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+
+			total.Add(total, amount)
+			if total.Cmp(limit) > 0 {
+				wait = max(ttl, time.Second)
+				return nil
+			}
+
+			expiration := window
+			if ttl > 0 {
+				expiration = redis.KeepTTL
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, key, total.String(), expiration)
+				return nil
+			})
+			return err
+		}, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			// concurrent charge, try again
+			return backoff.Retryable(err)
+		}
+		return err
+	})
+	if err != nil {
+		return time.Duration(0), err
+	}
+
+	return wait, nil
+}
+
+func (rl *RateLimiter) startSpan(ctx context.Context, name, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, trace.WithAttributes(
+		attribute.String("ratelimiter.key", key),
+	))
+}
+
+// Record is a rate-limit key together with its value (the time of the last
+// request, the count of requests, or the charged total) and its ttl.
+type Record struct {
+	Key   string        `json:"key"`
+	Value string        `json:"value"`
+	TTL   time.Duration `json:"ttl"`
+}
+
+// Find returns the records with the keys matching any of the glob patterns.
+func (rl *RateLimiter) Find(ctx context.Context, patterns ...string) ([]Record, error) {
+	keys := make([]string, 0)
+	for _, pattern := range patterns {
+		err := backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) error {
+			iter := rl.redis.Scan(ctx, 0, rl.prefix+pattern, 1000).Iterator()
+			for iter.Next(ctx) {
+				key := strings.TrimPrefix(iter.Val(), rl.prefix)
+				if !slices.Contains(keys, key) {
+					keys = append(keys, key)
+				}
+			}
+			return iter.Err()
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(keys)
+
+	records := make([]Record, 0, len(keys))
+	for _, key := range keys {
+		var (
+			value *redis.StringCmd
+			ttl   *redis.DurationCmd
+		)
+		err := backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
+			_, _err = rl.redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+				value = pipe.Get(ctx, rl.prefix+key)
+				ttl = pipe.PTTL(ctx, rl.prefix+key)
+				return nil
+			})
+			if errors.Is(_err, redis.Nil) {
+				// expired in the meantime
+				_err = nil
+			}
+			return
+		})
+		if err != nil {
+			return nil, err
+		}
+		if value.Err() != nil {
+			continue
+		}
+		records = append(records, Record{
+			Key:   key,
+			Value: value.Val(),
+			TTL:   ttl.Val(),
+		})
+	}
+
+	return records, nil
+}
+
+// Clear deletes the keys and returns the count of the ones that existed.
+func (rl *RateLimiter) Clear(ctx context.Context, keys ...string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, rl.prefix+key)
+	}
+
+	var deleted int64
+	err := backoff.Backoff(ctx, rl.backoffParams, func(ctx context.Context) (_err error) {
+		deleted, _err = rl.redis.Del(ctx, prefixed...).Result()
+		return
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
@@ -0,0 +1,46 @@
+package redisutils
+
+import (
+	"context"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/tracing"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+func Connect(cfg *config.Redis, backoffParams *backoff.Parameters) (*redis.Client, error) {
+	l := zap.L()
+
+	redisOptions, err := redis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	_redis := redis.NewClient(redisOptions)
+	_redis.AddHook(metrics.RedisHook{})
+	_redis.AddHook(tracing.RedisHook{})
+
+	l.Info("Connecting to redis...", zap.String("redis_url", cfg.URL))
+	err = backoff.Backoff(context.Background(), backoffParams, func(ctx context.Context) (_err error) {
+		status := _redis.Ping(ctx)
+		_err = status.Err()
+		if _err != nil {
+			l.Warn("Failed to connect to redis", zap.Error(_err))
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return _redis, nil
+}
+
+func Prefix(cfg *config.Redis) string {
+	if cfg.Namespace == "" {
+		return ""
+	}
+	return cfg.Namespace + ":"
+}
@@ -0,0 +1,197 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	ActionDeny   = "deny"
+	ActionReduce = "reduce"
+
+	ProviderGitHub  = "github"
+	ProviderTwitter = "twitter"
+)
+
+var (
+	ErrActionUnknown          = errors.New("unknown reputation action")
+	ErrFailedToFetchProfile   = errors.New("failed to fetch identity's profile")
+	ErrReputationInsufficient = errors.New("identity's reputation is insufficient")
+)
+
+// Verdict is the outcome of the reputation check.  Reasons explain why the
+// identity did not pass (empty if it did).
+type Verdict struct {
+	Passed  bool
+	Reasons []string
+}
+
+// Checker evaluates the reputation of the identities.
+type Checker interface {
+	Check(ctx context.Context, provider, username string) (*Verdict, error)
+
+	// Reload applies the changed reputation policy.
+	Reload(cfg *config.Config)
+}
+
+type checker struct {
+	backoffParams *backoff.Parameters
+	cfg           atomic.Pointer[config.Reputation]
+	prefix        string
+	providers     map[string]Provider
+	redis         *redis.Client
+}
+
+// New returns the checker that fetches the profiles from github and twitter
+// apis and caches them in redis.  It returns nil if the reputation checks are
+// not enabled.
+func New(cfg *config.Config, _redis *redis.Client) (Checker, error) {
+	if !cfg.Reputation.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Reputation.Action {
+	case ActionDeny, ActionReduce:
+		// ok
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrActionUnknown, cfg.Reputation.Action)
+	}
+
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	providers := map[string]Provider{
+		ProviderGitHub: NewGitHub(
+			cfg.Reputation.GithubAPIURL,
+			cfg.Reputation.GithubToken,
+		),
+	}
+	if cfg.Reputation.TwitterBearerToken != "" {
+		providers[ProviderTwitter] = NewTwitter(
+			cfg.Reputation.TwitterAPIURL,
+			cfg.Reputation.TwitterBearerToken,
+		)
+	}
+
+	c := &checker{
+		backoffParams: backoffParams,
+		prefix:        redisutils.Prefix(&cfg.Redis),
+		providers:     providers,
+		redis:         _redis,
+	}
+	c.Reload(cfg)
+
+	return c, nil
+}
+
+func (c *checker) Reload(cfg *config.Config) {
+	policy := cfg.Reputation
+	c.cfg.Store(&policy)
+}
+
+func (c *checker) Check(ctx context.Context, provider, username string) (*Verdict, error) {
+	p, known := c.providers[provider]
+	if !known {
+		// nothing to check for identities we can't look up
+		return &Verdict{Passed: true}, nil
+	}
+
+	profile, err := c.profile(ctx, provider, username, p)
+	if errors.Is(err, ErrProfileNotFound) {
+		return &Verdict{Reasons: []string{"profile not found"}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToFetchProfile, err)
+	}
+
+	return Evaluate(c.cfg.Load(), provider, profile), nil
+}
+
+func (c *checker) profile(ctx context.Context, provider, username string, p Provider) (*Profile, error) {
+	key := c.prefix + "reputation:" + provider + ":" + strings.ToLower(username)
+
+	var cached string
+	err := backoff.Backoff(ctx, c.backoffParams, func(ctx context.Context) (_err error) {
+		cached, _err = c.redis.Get(ctx, key).Result()
+		if errors.Is(_err, redis.Nil) {
+			// not cached yet
+			cached, _err = "", nil
+		}
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cached != "" {
+		profile := &Profile{}
+		if err := json.Unmarshal([]byte(cached), profile); err == nil {
+			return profile, nil
+		}
+	}
+
+	params := &backoff.Parameters{
+		BaseTimeout: c.cfg.Load().Timeout,
+	}
+	var profile *Profile
+	err = backoff.Backoff(ctx, params, func(ctx context.Context) (_err error) {
+		profile, _err = p.Profile(ctx, username)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return nil, err
+	}
+	err = backoff.Backoff(ctx, c.backoffParams, func(ctx context.Context) error {
+		return c.redis.Set(ctx, key, b, c.cfg.Load().CacheTTL).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// Evaluate applies the reputation policy to the profile.
+func Evaluate(cfg *config.Reputation, provider string, profile *Profile) *Verdict {
+	reasons := make([]string, 0)
+
+	if cfg.MinAccountAge > 0 && time.Since(profile.CreatedAt) < cfg.MinAccountAge {
+		reasons = append(reasons, fmt.Sprintf("account is younger than %s", cfg.MinAccountAge))
+	}
+	if profile.Followers < cfg.MinFollowers {
+		reasons = append(reasons, fmt.Sprintf("account has fewer than %d followers", cfg.MinFollowers))
+	}
+	if provider == ProviderGitHub {
+		if profile.PublicRepos < cfg.MinPublicRepos {
+			reasons = append(reasons, fmt.Sprintf("account has fewer than %d public repos", cfg.MinPublicRepos))
+		}
+		if len(cfg.GithubOrgs) > 0 && !slices.ContainsFunc(profile.Orgs, func(org string) bool {
+			return slices.ContainsFunc(cfg.GithubOrgs, func(required string) bool {
+				return strings.EqualFold(org, required)
+			})
+		}) {
+			reasons = append(reasons, "account is not a public member of "+strings.Join(cfg.GithubOrgs, ", "))
+		}
+	}
+
+	return &Verdict{
+		Passed:  len(reasons) == 0,
+		Reasons: reasons,
+	}
+}
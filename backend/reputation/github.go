@@ -0,0 +1,94 @@
+package reputation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGithubAPIURL = "https://api.github.com"
+)
+
+type GitHub struct {
+	apiURL string
+	client *http.Client
+	token  string
+}
+
+// NewGitHub returns the provider that looks up github users together with
+// their public org memberships (so that the orgs allowlist can be changed
+// without invalidating the cached profiles).
+func NewGitHub(apiURL, token string) *GitHub {
+	if apiURL == "" {
+		apiURL = defaultGithubAPIURL
+	}
+	return &GitHub{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		client: &http.Client{},
+		token:  token,
+	}
+}
+
+func (g *GitHub) Profile(ctx context.Context, username string) (*Profile, error) {
+	user := struct {
+		CreatedAt   time.Time `json:"created_at"`
+		Followers   int       `json:"followers"`
+		PublicRepos int       `json:"public_repos"`
+	}{}
+	if err := g.get(ctx, "/users/"+url.PathEscape(username), &user); err != nil {
+		return nil, err
+	}
+
+	profile := &Profile{
+		CreatedAt:   user.CreatedAt,
+		Followers:   user.Followers,
+		PublicRepos: user.PublicRepos,
+	}
+
+	orgs := []struct {
+		Login string `json:"login"`
+	}{}
+	if err := g.get(ctx, "/users/"+url.PathEscape(username)+"/orgs", &orgs); err != nil {
+		return nil, err
+	}
+	for _, org := range orgs {
+		profile.Orgs = append(profile.Orgs, org.Login)
+	}
+
+	return profile, nil
+}
+
+func (g *GitHub) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.apiURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	return doJSON(g.client, req, v)
+}
+
+func doJSON(client *http.Client, req *http.Request, v any) error {
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return ErrProfileNotFound
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: %s: %d", ErrProviderUnexpectedStatus, req.URL.Path, res.StatusCode)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -0,0 +1,26 @@
+package reputation
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrProfileNotFound          = errors.New("profile not found")
+	ErrProviderUnexpectedStatus = errors.New("unexpected identity provider's api response status")
+)
+
+// Profile is the subset of the identity's public profile that is relevant for
+// its reputation.
+type Profile struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Followers   int       `json:"followers"`
+	PublicRepos int       `json:"public_repos"`
+	Orgs        []string  `json:"orgs,omitempty"`
+}
+
+// Provider fetches the public profiles from identity provider's api.
+type Provider interface {
+	Profile(ctx context.Context, username string) (*Profile, error)
+}
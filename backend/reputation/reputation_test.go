@@ -0,0 +1,65 @@
+package reputation_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/reputation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stubGitHub(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/veteran", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"login":"veteran","created_at":"2012-01-01T00:00:00Z","followers":42,"public_repos":17}`))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/users/veteran/orgs", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`[{"login":"flashbots"}]`))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/users/sybil", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`{"login":"sybil","created_at":"` + time.Now().Format(time.RFC3339) + `","followers":0,"public_repos":0}`))
+		require.NoError(t, err)
+	})
+	mux.HandleFunc("/users/sybil/orgs", func(w http.ResponseWriter, _ *http.Request) {
+		_, err := w.Write([]byte(`[]`))
+		require.NoError(t, err)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestGitHubReputation(t *testing.T) {
+	srv := stubGitHub(t)
+	defer srv.Close()
+
+	cfg := &config.Reputation{
+		GithubOrgs:     []string{"Flashbots"},
+		MinAccountAge:  30 * 24 * time.Hour,
+		MinFollowers:   5,
+		MinPublicRepos: 3,
+	}
+	github := reputation.NewGitHub(srv.URL, "")
+
+	profile, err := github.Profile(context.Background(), "veteran")
+	require.NoError(t, err)
+	assert.Equal(t, 42, profile.Followers)
+	assert.Equal(t, []string{"flashbots"}, profile.Orgs)
+	verdict := reputation.Evaluate(cfg, reputation.ProviderGitHub, profile)
+	assert.True(t, verdict.Passed)
+	assert.Empty(t, verdict.Reasons)
+
+	profile, err = github.Profile(context.Background(), "sybil")
+	require.NoError(t, err)
+	verdict = reputation.Evaluate(cfg, reputation.ProviderGitHub, profile)
+	assert.False(t, verdict.Passed)
+	assert.Len(t, verdict.Reasons, 4)
+
+	_, err = github.Profile(context.Background(), "nobody")
+	assert.ErrorIs(t, err, reputation.ErrProfileNotFound)
+}
@@ -0,0 +1,62 @@
+package reputation
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTwitterAPIURL = "https://api.twitter.com"
+)
+
+type Twitter struct {
+	apiURL      string
+	bearerToken string
+	client      *http.Client
+}
+
+func NewTwitter(apiURL, bearerToken string) *Twitter {
+	if apiURL == "" {
+		apiURL = defaultTwitterAPIURL
+	}
+	return &Twitter{
+		apiURL:      strings.TrimSuffix(apiURL, "/"),
+		bearerToken: bearerToken,
+		client:      &http.Client{},
+	}
+}
+
+func (t *Twitter) Profile(ctx context.Context, username string) (*Profile, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		t.apiURL+"/2/users/by/username/"+url.PathEscape(username)+"?user.fields=created_at,public_metrics",
+		nil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+
+	res := struct {
+		Data *struct {
+			CreatedAt     time.Time `json:"created_at"`
+			PublicMetrics struct {
+				FollowersCount int `json:"followers_count"`
+			} `json:"public_metrics"`
+		} `json:"data"`
+	}{}
+	if err := doJSON(t.client, req, &res); err != nil {
+		return nil, err
+	}
+	if res.Data == nil {
+		// twitter responds with 200 and `errors` for unknown users
+		return nil, ErrProfileNotFound
+	}
+
+	return &Profile{
+		CreatedAt: res.Data.CreatedAt,
+		Followers: res.Data.PublicMetrics.FollowersCount,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package rpcutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"go.uber.org/zap"
+)
+
+var ErrNoEndpointReachable = errors.New("none of the rpc endpoints is reachable")
+
+// Connect returns the client of the first endpoint that answers (with its
+// chain id), the next ones are only tried once the backoff on the previous
+// one is exhausted.
+func Connect(cfg *config.RPC, backoffParams *backoff.Parameters) (*ethclient.Client, error) {
+	l := zap.L()
+
+	errs := make([]error, 0, len(cfg.Endpoints))
+	for _, endpoint := range cfg.Endpoints {
+		l.Info("Connecting to rpc endpoint...", zap.String("rpc_endpoint", endpoint))
+		var client *ethclient.Client
+		err := backoff.Backoff(context.Background(), backoffParams, func(ctx context.Context) (_err error) {
+			if client == nil {
+				if client, _err = ethclient.DialContext(ctx, endpoint); _err != nil {
+					l.Warn("Failed to connect to rpc endpoint", zap.Error(_err))
+					return _err
+				}
+			}
+			if _, _err = client.ChainID(ctx); _err != nil {
+				l.Warn("Failed to get chain id", zap.Error(_err))
+			}
+			return _err
+		})
+		if err == nil {
+			return client, nil
+		}
+		if client != nil {
+			client.Close()
+		}
+		l.Warn("Failing over to the next rpc endpoint",
+			zap.Error(err),
+			zap.String("rpc_endpoint", endpoint),
+		)
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrNoEndpointReachable, errors.Join(errs...))
+}
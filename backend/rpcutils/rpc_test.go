@@ -0,0 +1,34 @@
+package rpcutils_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/rpcutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnect(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x539"}`))
+	}))
+	defer up.Close()
+
+	params := &backoff.Parameters{BaseTimeout: 10 * time.Millisecond, TotalTimeout: 100 * time.Millisecond}
+
+	// fails over to the next endpoint
+	client, err := rpcutils.Connect(&config.RPC{Endpoints: []string{down.URL, up.URL}}, params)
+	require.NoError(t, err)
+	client.Close()
+
+	_, err = rpcutils.Connect(&config.RPC{Endpoints: []string{down.URL}}, params)
+	assert.ErrorIs(t, err, rpcutils.ErrNoEndpointReachable)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+type adminContextKey string
+
+const adminIdentityContextKey adminContextKey = "admin"
+
+var (
+	ErrAdminClientCAInvalid          = errors.New("admin client ca file contains no certificates")
+	ErrAdminServerFailedToInitialise = errors.New("failed to initialise admin server")
+)
+
+type responseAdminError struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) adminServer() (*http.Server, error) {
+	cfg := s.config().Admin
+
+	// the handlers are authenticated one by one (rather than the whole mux),
+	// so that the request span gets named after the route the mux matched
+	mux := http.NewServeMux()
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.Handle(pattern, s.adminAuth(handler))
+	}
+	handle("GET /admin/status", s.handleAdminStatus)
+	handle("POST /admin/pause", s.handleAdminPause)
+	handle("POST /admin/resume", s.handleAdminResume)
+	handle("PUT /admin/payout", s.handleAdminPayout)
+	handle("GET /admin/ratelimits", s.handleAdminRatelimits)
+	handle("DELETE /admin/ratelimits", s.handleAdminRatelimitsClear)
+	handle("GET /admin/denylist", s.handleAdminDenylist)
+	handle("POST /admin/denylist", s.handleAdminDenylistAdd)
+	handle("DELETE /admin/denylist/{entry...}", s.handleAdminDenylistRemove)
+	handle("GET /admin/transactions/pending", s.handleAdminPendingTransactions)
+	handle("POST /admin/nonce/resync", s.handleAdminNonceResync)
+	handle("GET /admin/webhooks/dead-letters", s.handleAdminWebhooksDeadLetters)
+
+	srv := &http.Server{
+		Addr:              cfg.ListenAddress,
+		Handler:           httplogger.Middleware(s.log, mux),
+		ReadHeaderTimeout: 30 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	if cfg.ClientCA != "" {
+		pem, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAdminServerFailedToInitialise, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %w: %s", ErrAdminServerFailedToInitialise, ErrAdminClientCAInvalid, cfg.ClientCA)
+		}
+		clientAuth := tls.RequireAndVerifyClientCert
+		if cfg.Token != "" {
+			// either of the two will do
+			clientAuth = tls.VerifyClientCertIfGiven
+		}
+		srv.TLSConfig = &tls.Config{
+			ClientAuth: clientAuth,
+			ClientCAs:  pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	return srv, nil
+}
+
+func (s *Server) runAdminServer(srv *http.Server) {
+	l := s.log
+	cfg := s.config().Admin
+
+	l.Info("Starting up admin server...",
+		zap.String("admin_listen_address", cfg.ListenAddress),
+		zap.Bool("tls", cfg.TLSCert != ""),
+	)
+
+	var err error
+	if cfg.TLSCert != "" {
+		err = srv.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		l.Error("Admin server failed", zap.Error(err))
+	}
+}
+
+// adminAuth lets through the requests that either come with a verified client
+// certificate, or carry the admin token.
+func (s *Server) adminAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := s.config().Admin.Token
+
+		var admin string
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			admin = "cert:" + r.TLS.VerifiedChains[0][0].Subject.String()
+		} else if bearer, ok := strings.CutPrefix(r.Header.Get("authorization"), "Bearer "); ok &&
+			token != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+			admin = "token"
+		}
+
+		if admin == "" {
+			l := logutils.LoggerFromRequest(r)
+			l.Warn("Unauthorised admin request",
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.EscapedPath()),
+				zap.String("remote_addr", r.RemoteAddr),
+			)
+			s.httpError(w, r, errAuthRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(
+			context.WithValue(r.Context(), adminIdentityContextKey, admin),
+		))
+	})
+}
+
+// audit records the admin action into the audit log.
+func (s *Server) audit(r *http.Request, action string, err error, fields ...zap.Field) {
+	admin, _ := r.Context().Value(adminIdentityContextKey).(string)
+
+	fields = append([]zap.Field{
+		zap.String("logType", "audit"),
+		zap.String("action", action),
+		zap.String("admin", admin),
+		zap.String("remote_addr", r.RemoteAddr),
+	}, fields...)
+
+	if err != nil {
+		s.auditLog.Warn("Admin action failed", append(fields, zap.Error(err))...)
+		return
+	}
+	s.auditLog.Info("Admin action", fields...)
+}
+
+func (s *Server) renderAdminError(w http.ResponseWriter, r *http.Request, code int, err error) {
+	if err := s.renderJSON(w, code, &responseAdminError{Error: err.Error()}); err != nil {
+		l := logutils.LoggerFromRequest(r)
+		l.Error("Failed to send admin response", zap.Error(err))
+	}
+}
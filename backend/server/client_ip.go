@@ -0,0 +1,31 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientIP returns the address of the client as seen by the outermost of the
+// reverse proxies in front of the server.
+func (s *Server) clientIP(r *http.Request) (string, error) {
+	cfg := s.config()
+	if cfg.Server.ProxyCount == 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			return r.RemoteAddr, nil //nolint:nilerr
+		}
+		return host, nil
+	}
+
+	forwardedFor := strings.Split(r.Header.Get("x-forwarded-for"), ",")
+	if len(forwardedFor) < cfg.Server.ProxyCount {
+		return "", fmt.Errorf("%w: %d", ErrRatelimiterTooFewProxies, len(forwardedFor))
+	}
+	ip := strings.TrimSpace(forwardedFor[len(forwardedFor)-cfg.Server.ProxyCount])
+	if ip == "" {
+		return "", fmt.Errorf("%w: %d", ErrRatelimiterTooFewProxies, 0)
+	}
+	return ip, nil
+}
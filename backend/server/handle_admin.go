@@ -0,0 +1,365 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/denylist"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/ratelimiter"
+	"github.com/flashbots/eth-faucet/txbuilder"
+	"github.com/flashbots/eth-faucet/units"
+	"github.com/flashbots/eth-faucet/webhook"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAdminAddressInvalid        = errors.New("invalid address")
+	ErrAdminDenylistEntryNotFound = errors.New("denylist entry not found")
+	ErrAdminIPInvalid             = errors.New("invalid ip")
+	ErrAdminRatelimitQueryMissing = errors.New("either address, ip, or provider and username must be given")
+)
+
+type responseAdminStatus struct {
+	Address     string               `json:"address"`
+	Maintenance *responseMaintenance `json:"maintenance"`
+	Nonce       uint64               `json:"nonce"`
+	Payout      string               `json:"payout"`
+	PayoutWei   string               `json:"payout_wei"`
+}
+
+type requestAdminPause struct {
+	Message  string    `json:"message,omitempty"`
+	ResumeAt time.Time `json:"resume_at,omitempty"`
+}
+
+type requestAdminPayout struct {
+	Payout string `json:"payout"`
+}
+
+type responseAdminPayout struct {
+	Changes []string `json:"changes"`
+}
+
+type responseAdminRatelimits struct {
+	Records []ratelimiter.Record `json:"records"`
+}
+
+type responseAdminRatelimitsClear struct {
+	Cleared int64 `json:"cleared"`
+}
+
+type requestAdminDenylist struct {
+	Entry string `json:"entry"`
+}
+
+type responseAdminDenylist struct {
+	Entries []denylist.Entry `json:"entries"`
+}
+
+type responseAdminPendingTransactions struct {
+	Transactions []*txbuilder.PendingTransaction `json:"transactions"`
+}
+
+type responseAdminNonceResync struct {
+	Before uint64 `json:"before"`
+	After  uint64 `json:"after"`
+}
+
+type responseAdminWebhooksDeadLetters struct {
+	DeadLetters []webhook.DeadLetter `json:"dead_letters"`
+}
+
+func (s *Server) handleAdminStatus(w http.ResponseWriter, r *http.Request) {
+	cfg := s.config()
+
+	payout, err := cfg.Faucet.PayoutWei(cfg.Chain.TokenDecimals)
+	s.audit(r, "view_status", err)
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminStatus{
+		Address:     s.txbuilder.Address(),
+		Maintenance: newResponseMaintenance(s.maintenance.Status(time.Now())),
+		Nonce:       s.txbuilder.Nonce(),
+		Payout:      units.Format(payout, cfg.Chain.TokenDecimals),
+		PayoutWei:   payout.String(),
+	})
+}
+
+// handleAdminPause switches the maintenance on (the message and the expected
+// resume time are optional).
+func (s *Server) handleAdminPause(w http.ResponseWriter, r *http.Request) {
+	request := &requestAdminPause{}
+	if r.ContentLength != 0 {
+		if err := s.parseRequest(r, request); err != nil {
+			s.audit(r, "pause", err)
+			s.renderAdminError(w, r, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	s.maintenance.Enable(request.Message, request.ResumeAt)
+	s.audit(r, "pause", nil,
+		zap.String("message", request.Message),
+		zap.Time("resume_at", request.ResumeAt),
+	)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminResume switches off the maintenance that was switched on with
+// the admin api (the one enabled in the config, or the schedule, stay).
+func (s *Server) handleAdminResume(w http.ResponseWriter, r *http.Request) {
+	wasPaused := s.maintenance.Disable()
+	s.audit(r, "resume", nil, zap.Bool("was_paused", wasPaused))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminPayout changes the default payout until the next reload of the
+// config file (or restart).
+func (s *Server) handleAdminPayout(w http.ResponseWriter, r *http.Request) {
+	request := &requestAdminPayout{}
+	if err := s.parseRequest(r, request); err != nil {
+		s.audit(r, "change_payout", err)
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	s.mxConfig.Lock()
+	defer s.mxConfig.Unlock()
+
+	current := s.config()
+	next := *current
+	next.Faucet.Payout = request.Payout
+	if err := next.Validate(); err != nil {
+		s.audit(r, "change_payout", err, zap.String("payout", request.Payout))
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	s.cfg.Store(&next)
+
+	changes := changesToStrings(config.Diff(current, &next))
+	s.audit(r, "change_payout", nil, zap.Strings("changes", changes))
+	s.renderAdminResponse(w, r, &responseAdminPayout{Changes: changes})
+}
+
+func (s *Server) handleAdminRatelimits(w http.ResponseWriter, r *http.Request) {
+	patterns, err := adminRatelimitPatterns(r)
+	if err != nil {
+		s.audit(r, "view_ratelimits", err)
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := s.ratelimiter.Find(r.Context(), patterns...)
+	s.audit(r, "view_ratelimits", err, zap.Strings("patterns", patterns))
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminRatelimits{Records: records})
+}
+
+func (s *Server) handleAdminRatelimitsClear(w http.ResponseWriter, r *http.Request) {
+	patterns, err := adminRatelimitPatterns(r)
+	if err != nil {
+		s.audit(r, "view_ratelimits", err)
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	records, err := s.ratelimiter.Find(r.Context(), patterns...)
+	s.audit(r, "view_ratelimits", err, zap.Strings("patterns", patterns))
+	if err != nil {
+		s.audit(r, "clear_ratelimits", err, zap.Strings("patterns", patterns))
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	keys := make([]string, 0, len(records))
+	for _, record := range records {
+		keys = append(keys, record.Key)
+	}
+
+	cleared, err := s.ratelimiter.Clear(r.Context(), keys...)
+	s.audit(r, "clear_ratelimits", err, zap.Strings("keys", keys))
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminRatelimitsClear{Cleared: cleared})
+}
+
+func (s *Server) handleAdminDenylist(w http.ResponseWriter, r *http.Request) {
+	entries, err := s.denylist.List(r.Context())
+	s.audit(r, "view_denylist", err)
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminDenylist{Entries: entries})
+}
+
+func (s *Server) handleAdminDenylistAdd(w http.ResponseWriter, r *http.Request) {
+	request := &requestAdminDenylist{}
+	if err := s.parseRequest(r, request); err != nil {
+		s.audit(r, "denylist_add", err)
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+	entry, err := denylist.ParseEntry(request.Entry)
+	if err != nil {
+		s.audit(r, "denylist_add", err, zap.String("entry", request.Entry))
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.denylist.Add(r.Context(), entry)
+	s.audit(r, "denylist_add", err, zap.String("entry", entry.String()))
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleAdminDenylistRemove(w http.ResponseWriter, r *http.Request) {
+	entry, err := denylist.ParseEntry(r.PathValue("entry"))
+	if err != nil {
+		s.audit(r, "denylist_remove", err, zap.String("entry", r.PathValue("entry")))
+		s.renderAdminError(w, r, http.StatusBadRequest, err)
+		return
+	}
+
+	removed, err := s.denylist.Remove(r.Context(), entry)
+	if err == nil && !removed {
+		err = fmt.Errorf("%w: %s", ErrAdminDenylistEntryNotFound, entry)
+	}
+	s.audit(r, "denylist_remove", err, zap.String("entry", entry.String()))
+	switch {
+	case errors.Is(err, ErrAdminDenylistEntryNotFound):
+		s.renderAdminError(w, r, http.StatusNotFound, err)
+	case err != nil:
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (s *Server) handleAdminPendingTransactions(w http.ResponseWriter, r *http.Request) {
+	pending, err := s.txbuilder.Pending(r.Context())
+	s.audit(r, "view_pending_transactions", err)
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminPendingTransactions{Transactions: pending})
+}
+
+func (s *Server) handleAdminNonceResync(w http.ResponseWriter, r *http.Request) {
+	before, after, err := s.txbuilder.ResyncNonce(r.Context())
+	s.audit(r, "nonce_resync", err, zap.Uint64("nonce_before", before), zap.Uint64("nonce_after", after))
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminNonceResync{Before: before, After: after})
+}
+
+func (s *Server) handleAdminWebhooksDeadLetters(w http.ResponseWriter, r *http.Request) {
+	deadLetters := []webhook.DeadLetter{}
+	var err error
+	if s.webhooks != nil {
+		deadLetters, err = s.webhooks.DeadLetters(r.Context())
+	}
+	s.audit(r, "view_webhook_dead_letters", err)
+	if err != nil {
+		s.renderAdminError(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.renderAdminResponse(w, r, &responseAdminWebhooksDeadLetters{DeadLetters: deadLetters})
+}
+
+func (s *Server) renderAdminResponse(w http.ResponseWriter, r *http.Request, v any) {
+	if err := s.renderJSON(w, http.StatusOK, v); err != nil {
+		l := logutils.LoggerFromRequest(r)
+		l.Error("Failed to send admin response", zap.Error(err))
+	}
+}
+
+// adminRatelimitPatterns returns the patterns of the rate-limit keys of the
+// address (in any letter case), of the ip or of the identity.
+func adminRatelimitPatterns(r *http.Request) ([]string, error) {
+	q := r.URL.Query()
+	patterns := make([]string, 0)
+
+	if address := q.Get("address"); address != "" {
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("%w: %s", ErrAdminAddressInvalid, address)
+		}
+		address := caseInsensitivePattern(address)
+		patterns = append(patterns,
+			"address:"+address,
+			"full:*:"+address,
+		)
+	}
+
+	if ip := q.Get("ip"); ip != "" {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("%w: %s", ErrAdminIPInvalid, ip)
+		}
+		patterns = append(patterns, "ip:"+escapePattern(ip))
+	}
+
+	if provider, username := q.Get("provider"), q.Get("username"); provider != "" && username != "" {
+		identity := escapePattern(provider) + ":" + escapePattern(username)
+		patterns = append(patterns,
+			"allowance:"+identity,
+			"full:"+identity+":*",
+			"identity:"+identity,
+			"quota:"+identity,
+		)
+	}
+
+	if len(patterns) == 0 {
+		return nil, ErrAdminRatelimitQueryMissing
+	}
+	return patterns, nil
+}
+
+func escapePattern(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`*`, `\*`,
+		`?`, `\?`,
+		`[`, `\[`,
+		`]`, `\]`,
+	).Replace(s)
+}
+
+func caseInsensitivePattern(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		lower, upper := strings.ToLower(string(c)), strings.ToUpper(string(c))
+		if lower == upper {
+			b.WriteString(escapePattern(string(c)))
+			continue
+		}
+		b.WriteString("[" + lower + upper + "]")
+	}
+	return b.String()
+}
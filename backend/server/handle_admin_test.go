@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/denylist"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestAdminReadsAudited(t *testing.T) {
+	d, err := denylist.New(&config.Config{
+		Redis: config.Redis{Timeout: time.Second},
+	}, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+	core, audited := observer.New(zapcore.InfoLevel)
+	s := &Server{auditLog: zap.New(core), denylist: d}
+
+	w := httptest.NewRecorder()
+	s.handleAdminDenylist(w, httptest.NewRequest(http.MethodGet, "/admin/denylist", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	s.handleAdminWebhooksDeadLetters(w, httptest.NewRequest(http.MethodGet, "/admin/webhooks/dead-letters", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	s.handleAdminRatelimits(w, httptest.NewRequest(http.MethodGet, "/admin/ratelimits", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	actions := make([]string, 0)
+	for _, entry := range audited.All() {
+		assert.Equal(t, "audit", entry.ContextMap()["logType"])
+		actions = append(actions, entry.ContextMap()["action"].(string))
+	}
+	assert.Equal(t, []string{"view_denylist", "view_webhook_dead_letters", "view_ratelimits"}, actions)
+	assert.Equal(t, zapcore.WarnLevel, audited.All()[2].Level)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+const (
+	providerPoW = "pow"
+)
+
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request) {
+	l := logutils.LoggerFromRequest(r)
+
+	if s.methodNotAllowed(w, r, http.MethodGet) {
+		return
+	}
+
+	ip, err := s.clientIP(r)
+	if err != nil {
+		l.Warn("Failed to determine client ip", zap.Error(err))
+		s.httpError(w, r, errRequestInvalid)
+		return
+	}
+
+	challenge, err := s.pow.Issue(r.Context(), ip)
+	if err != nil {
+		l.Error("Failed to issue pow challenge", zap.Error(err))
+		s.httpError(w, r, errServiceUnavailable)
+		return
+	}
+
+	if err := s.renderJSON(w, http.StatusOK, challenge); err != nil {
+		l.Error("Failed to send challenge response", zap.Error(err))
+	}
+}
@@ -6,27 +6,50 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/denylist"
+	"github.com/flashbots/eth-faucet/ledger"
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/reputation"
+	"github.com/flashbots/eth-faucet/units"
+	"github.com/flashbots/eth-faucet/webhook"
 	"github.com/golang-jwt/jwt/v5"
 	"go.uber.org/zap"
 )
 
+const (
+	providerAPIKey = "apikey"
+	providerCert   = "cert"
+)
+
 var (
+	ErrAPIKeyAddressNotAllowed      = errors.New("address is not allowed for the api key")
 	ErrAuthorisationHeaderMalformed = errors.New("authorisation header is malformed")
 	ErrAuthorisationHeaderMissing   = errors.New("authorisation header is missing")
 	ErrJWTFailedToParse             = errors.New("failed to parse jwt token")
 	ErrJWTInvalidSchema             = errors.New("jwt token has unrecognised schema")
 	ErrRatelimiterTooFewProxies     = errors.New("too few proxies")
+	ErrRequestAddressInvalid        = errors.New("invalid address")
+	ErrRequestAmountInvalid         = errors.New("invalid amount")
 	ErrRequestFailedToParse         = errors.New("failed to parse request body")
 	ErrRequestFailedToRead          = errors.New("failed to read request body")
 )
 
 type requestFund struct {
-	Address string `json:"address"`
+	Address      string `json:"address"`
+	Amount       string `json:"amount,omitempty"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+	PoWChallenge string `json:"pow_challenge,omitempty"`
+	PoWNonce     string `json:"pow_nonce,omitempty"`
 }
 
 type responseFund struct {
@@ -37,86 +60,226 @@ type jwtFund struct {
 	jwt.RegisteredClaims
 
 	Provider string `json:"provider"`
+	Tier     string `json:"tier,omitempty"`
 	Username string `json:"username"`
 }
 
 func (s *Server) handleFund(w http.ResponseWriter, r *http.Request) {
 	l := logutils.LoggerFromRequest(r)
 
-	if r.Method != http.MethodPost {
-		s.httpError(w, http.StatusNotImplemented)
+	if s.methodNotAllowed(w, r, http.MethodPost) {
+		return
+	}
+
+	if status := s.maintenance.Status(time.Now()); status.Active {
+		metrics.RecordFund(metrics.FundOutcomeMaintenance)
+		s.renderMaintenance(w, r, status)
 		return
 	}
 
-	claims, err := s.authoriseRequestFund(r)
+	claims, key, err := s.authoriseRequestFund(r)
 	if err != nil {
-		if errors.Is(err, jwt.ErrTokenExpired) {
-			http.Error(
-				w,
-				"Error: your api token is expired, please try refreshing the page",
-				http.StatusForbidden,
-			)
-			return
+		metrics.RecordFund(metrics.FundOutcomeUnauthorised)
+		if !errors.Is(err, jwt.ErrTokenExpired) {
+			l.Warn("Failed to authorise fund request", zap.Error(err))
 		}
-
-		l.Warn("Failed to authorise fund request", zap.Error(err))
-		s.httpError(w, http.StatusForbidden)
+		s.httpError(w, r, authoriseError(err))
 		return
 	}
 
 	request, err := s.parseRequestFund(r)
 	if err != nil {
 		l.Warn("Failed to parse fund request", zap.Error(err))
-		s.httpError(w, http.StatusBadRequest)
+		metrics.RecordFund(ledger.StatusInvalid)
+		if errors.Is(err, ErrRequestAddressInvalid) {
+			s.httpError(w, r, errAddressInvalid)
+		} else {
+			s.httpError(w, r, errRequestInvalid)
+		}
 		return
 	}
 
-	wait, err := s.ratelimitRequestFund(r, claims, request)
+	if idempotencyKey := r.Header.Get(headerIdempotencyKey); idempotencyKey != "" && s.idempotency != nil {
+		s.idempotentRequestFund(w, r, claims, request, idempotencyKey, func(w http.ResponseWriter) {
+			s.fund(w, r, claims, key, request)
+		})
+		return
+	}
+
+	s.fund(w, r, claims, key, request)
+}
+
+// fund sends the payout for the authorised and parsed request (unless any of
+// the checks fails).
+func (s *Server) fund(
+	w http.ResponseWriter, r *http.Request, claims *jwtFund, key *apikey.Key, request *requestFund,
+) {
+	l := logutils.LoggerFromRequest(r)
+	cfg := s.config()
+
+	attempt := s.newLedgerEntry(r, claims, key, request)
+	defer s.recordFundAttempt(r, attempt)
+
+	denied, err := s.denylistRequestFund(r, claims, request)
+	if errors.Is(err, ErrRatelimiterTooFewProxies) {
+		l.Warn("Failed to check the denylist", zap.Error(err))
+		attempt.Status, attempt.Error = ledger.StatusInvalid, err.Error()
+		s.httpError(w, r, errRequestInvalid)
+		return
+	}
 	if err != nil {
-		l.Warn("Failed to rate-limit fund request", zap.Error(err))
-		s.httpError(w, http.StatusTooManyRequests)
+		l.Error("Failed to check the denylist", zap.Error(err))
+		attempt.Error = err.Error()
+		s.httpError(w, r, errServiceUnavailable)
 		return
 	}
-	if wait > time.Duration(0) {
-		err = s.renderJSON(w, http.StatusTooManyRequests, &responseFund{
-			Message: "Too many requests, come back in " + wait.Round(time.Second).String(),
-		})
+	if denied != nil {
+		attempt.Status, attempt.Error = ledger.StatusDenied, "denylisted: "+denied.String()
+		s.httpError(w, r, errDenied)
+		return
+	}
+
+	if claims.Provider == providerPoW && key == nil {
+		if err := s.verifyPoWRequestFund(r, request); err != nil {
+			l.Warn("Failed to verify pow solution", zap.Error(err))
+			attempt.Status, attempt.Error = ledger.StatusInvalid, err.Error()
+			s.httpError(w, r, errPoWFailed)
+			return
+		}
+	}
+
+	if key == nil && claims.Provider != providerCert && s.captcha != nil {
+		if err := s.verifyCaptchaRequestFund(r, request); err != nil {
+			l.Warn("Failed to verify captcha", zap.Error(err))
+			attempt.Status, attempt.Error = ledger.StatusInvalid, err.Error()
+			s.httpError(w, r, errCaptchaFailed)
+			return
+		}
+	}
+
+	if key != nil && !key.IsAddressAllowed(request.Address) {
+		l.Warn("Failed to authorise fund request",
+			zap.Error(ErrAPIKeyAddressNotAllowed),
+			zap.String("address_to", request.Address),
+			zap.String("api_key_id", key.ID),
+		)
+		attempt.Status, attempt.Error = ledger.StatusInvalid, ErrAPIKeyAddressNotAllowed.Error()
+		s.httpError(w, r, errAddressNotAllowed)
+		return
+	}
+
+	verdict := &reputation.Verdict{Passed: true}
+	if key == nil && s.reputation != nil {
+		verdict, err = s.reputation.Check(r.Context(), claims.Provider, claims.Username)
 		if err != nil {
-			l := logutils.LoggerFromRequest(r)
-			l.Error("Failed to send fund response", zap.Error(err))
+			l.Error("Failed to check identity's reputation", zap.Error(err))
+			attempt.Error = err.Error()
+			s.httpError(w, r, errServiceUnavailable)
+			return
 		}
+		if !verdict.Passed {
+			l.Info("Identity's reputation is insufficient",
+				zap.String("identity_provider", claims.Provider),
+				zap.String("identity_username", claims.Username),
+				zap.Strings("reasons", verdict.Reasons),
+			)
+		}
+		if !verdict.Passed && cfg.Reputation.Action == reputation.ActionDeny {
+			attempt.Status, attempt.Error = ledger.StatusIneligible, strings.Join(verdict.Reasons, ", ")
+			s.httpError(w, r, errIneligible.withMessage(
+				errIneligible.message+": "+strings.Join(verdict.Reasons, ", "),
+			))
+			return
+		}
+	}
+
+	amount, err := s.amountRequestFund(cfg, claims, key, request)
+	if err != nil {
+		if !errors.Is(err, ErrRequestAmountInvalid) {
+			l.Error("Failed to determine payout amount", zap.Error(err))
+			attempt.Error = err.Error()
+			s.httpError(w, r, errInternal)
+			return
+		}
+		l.Warn("Failed to determine payout amount", zap.Error(err))
+		attempt.Status, attempt.Error = ledger.StatusInvalid, err.Error()
+		s.httpError(w, r, errAmountInvalid.withMessage(
+			"Amount "+strings.TrimPrefix(err.Error(), ErrRequestAmountInvalid.Error()+": "),
+		))
+		return
+	}
+	if !verdict.Passed {
+		amount.Mul(amount, big.NewInt(cfg.Reputation.ReducedPayoutPercent))
+		amount.Div(amount, big.NewInt(100))
+	}
+	attempt.Amount = amount
+
+	var wait time.Duration
+	if key != nil {
+		wait, err = s.ratelimitRequestFundAPIKey(r, key)
+	} else {
+		wait, err = s.ratelimitRequestFund(r, cfg, claims, request, amount)
+	}
+	if err != nil {
+		l.Warn("Failed to rate-limit fund request", zap.Error(err))
+		attempt.Error = err.Error()
+		if errors.Is(err, ErrRatelimiterTooFewProxies) {
+			s.httpError(w, r, errRequestInvalid)
+		} else {
+			s.httpError(w, r, errServiceUnavailable)
+		}
+		return
+	}
+	if wait > time.Duration(0) {
+		attempt.Status, attempt.Error = ledger.StatusRateLimited, "come back in "+wait.Round(time.Second).String()
+		w.Header().Set("Retry-After", strconv.Itoa(int(max(wait.Round(time.Second), time.Second).Seconds())))
+		s.httpError(w, r, errRateLimited.withMessage(
+			errRateLimited.message+", come back in "+wait.Round(time.Second).String(),
+		))
 		return
 	}
 
-	txHash, err := s.txbuilder.SendFunds(r.Context(), request.Address, s.cfg.Faucet.PayoutWei())
+	s.notifyFund(r, webhook.EventFundRequested, attempt)
+
+	tx, err := s.txbuilder.SendFunds(r.Context(), request.Address, amount)
+	var txHash common.Hash
+	if tx != nil {
+		txHash = tx.Hash()
+		nonce := tx.Nonce()
+		attempt.TxHash, attempt.Nonce = txHash.Hex(), &nonce
+	}
+	if key != nil {
+		s.recordAPIKeyUsage(r, key, request, txHash, err)
+	}
 	if err != nil {
 		l.Error("Failed to send funds",
 			zap.Error(err),
-			zap.Int64("amount", s.cfg.Faucet.Payout),
+			zap.String("amount", units.Format(amount, cfg.Chain.TokenDecimals)),
+			zap.String("amount_wei", amount.String()),
 			zap.String("address_from", s.txbuilder.Address()),
 			zap.String("address_to", request.Address),
 			zap.String("identity_provider", claims.Provider),
 			zap.String("identity_username", claims.Username),
 			zap.String("tx_hash", txHash.Hex()),
 		)
-		err = s.renderJSON(w, http.StatusOK, &responseFund{
-			Message: "Error: " + err.Error(),
-		})
-		if err != nil {
-			l := logutils.LoggerFromRequest(r)
-			l.Error("Failed to send fund response", zap.Error(err))
-		}
+		attempt.Error = err.Error()
+		s.notifyFund(r, webhook.EventFundFailed, attempt)
+		s.httpError(w, r, sendFundsError(err))
 		return
 	}
 
+	sentAt := time.Now().UTC()
+	attempt.Status, attempt.SentAt = ledger.StatusSent, &sentAt
 	l.Info("Sent funds",
-		zap.Int64("amount", s.cfg.Faucet.Payout),
+		zap.String("amount", units.Format(amount, cfg.Chain.TokenDecimals)),
+		zap.String("amount_wei", amount.String()),
 		zap.String("address_from", s.txbuilder.Address()),
 		zap.String("address_to", request.Address),
 		zap.String("identity_provider", claims.Provider),
 		zap.String("identity_username", claims.Username),
 		zap.String("tx_hash", txHash.Hex()),
 	)
+	s.notifyFund(r, webhook.EventFundSent, attempt)
 
 	err = s.renderJSON(w, http.StatusOK, &responseFund{
 		Message: "TxHash: " + txHash.Hex(),
@@ -128,69 +291,239 @@ func (s *Server) handleFund(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) authoriseRequestFund(r *http.Request) (
-	*jwtFund, error,
+	*jwtFund, *apikey.Key, error,
 ) {
+	cfg := s.config()
 	authorizationHeader := r.Header.Get("authorization")
+	if authorizationHeader == "" && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		// an internal caller with the client certificate (verified against
+		// the server's client ca)
+		subject := r.TLS.VerifiedChains[0][0].Subject
+		username := subject.CommonName
+		if username == "" {
+			username = subject.String()
+		}
+		return &jwtFund{
+			Provider: providerCert,
+			Username: username,
+		}, nil, nil
+	}
+	if authorizationHeader == "" && s.pow != nil {
+		// the identity is the client's ip, the proof is verified once the
+		// request body is parsed
+		ip, err := s.clientIP(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &jwtFund{
+			Provider: providerPoW,
+			Username: ip,
+		}, nil, nil
+	}
 	if authorizationHeader == "" {
-		return nil, ErrAuthorisationHeaderMissing
+		return nil, nil, ErrAuthorisationHeaderMissing
 	}
 	if !strings.HasPrefix(authorizationHeader, "Bearer ") {
-		return nil, ErrAuthorisationHeaderMalformed
+		return nil, nil, ErrAuthorisationHeaderMalformed
 	}
 	tokenString := strings.TrimPrefix(authorizationHeader, "Bearer ")
 
+	if apikey.IsToken(tokenString) {
+		key, err := s.apikeys.Authenticate(r.Context(), tokenString)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &jwtFund{
+			Provider: providerAPIKey,
+			Username: key.ID,
+		}, key, nil
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &jwtFund{}, func(_ *jwt.Token) (interface{}, error) {
-		return []byte(s.cfg.Server.AuthSecret), nil
+		return []byte(cfg.Server.AuthSecret), nil
 	}, jwt.WithLeeway(5*time.Second))
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrJWTFailedToParse, err)
+		return nil, nil, fmt.Errorf("%w: %w", ErrJWTFailedToParse, err)
 	}
 	claims, ok := token.Claims.(*jwtFund)
 	if !ok {
-		return nil, ErrJWTInvalidSchema
+		return nil, nil, ErrJWTInvalidSchema
+	}
+	return claims, nil, nil
+}
+
+// authoriseError tells the client why the request is not authorised.
+func authoriseError(err error) apiError {
+	switch {
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return errAuthExpired
+	case errors.Is(err, ErrAuthorisationHeaderMissing):
+		return errAuthRequired
+	case errors.Is(err, ErrRatelimiterTooFewProxies):
+		return errRequestInvalid
+	case errors.Is(err, ErrAuthorisationHeaderMalformed),
+		errors.Is(err, ErrJWTFailedToParse),
+		errors.Is(err, ErrJWTInvalidSchema),
+		errors.Is(err, apikey.ErrKeyMalformed),
+		errors.Is(err, apikey.ErrKeyMismatch),
+		errors.Is(err, apikey.ErrKeyNotFound),
+		errors.Is(err, apikey.ErrKeyRevoked):
+		return errAuthInvalid
+	default:
+		return errServiceUnavailable
 	}
-	return claims, nil
 }
 
 func (s *Server) parseRequestFund(r *http.Request) (
 	*requestFund, error,
 ) {
-	body, err := io.ReadAll(io.LimitReader(r.Body, int64(s.cfg.Server.MaxRequestBodySize)))
+	request := &requestFund{}
+	if err := s.parseRequest(r, request); err != nil {
+		return nil, err
+	}
+	if !common.IsHexAddress(request.Address) {
+		return nil, fmt.Errorf("%w: %s", ErrRequestAddressInvalid, request.Address)
+	}
+	return request, nil
+}
+
+func (s *Server) parseRequest(r *http.Request, request any) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(s.config().Server.MaxRequestBodySize)))
 	defer r.Body.Close()
 	if err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrRequestFailedToRead, err)
+		return fmt.Errorf("%w: %w", ErrRequestFailedToRead, err)
 	}
 
 	d := json.NewDecoder(bytes.NewReader(body))
 	d.DisallowUnknownFields()
 
-	request := &requestFund{}
 	if err := d.Decode(request); err != nil {
-		return nil, fmt.Errorf("%w: %w", ErrRequestFailedToParse, err)
+		return fmt.Errorf("%w: %w", ErrRequestFailedToParse, err)
 	}
 
 	r.Body = io.NopCloser(bytes.NewReader(body))
-	return request, nil
+	return nil
+}
+
+// denylistRequestFund checks the request against the denylist, and logs the
+// denied ones separately (so that they are easy to find).
+func (s *Server) denylistRequestFund(
+	r *http.Request, claims *jwtFund, request *requestFund,
+) (*denylist.Entry, error) {
+	l := logutils.LoggerFromRequest(r)
+
+	// without the ip the ip entries would be silently skipped
+	ip, err := s.clientIP(r)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.denylist.Match(r.Context(), denylist.Request{
+		Address:  request.Address,
+		IP:       ip,
+		Provider: claims.Provider,
+		Username: claims.Username,
+	})
+	if err != nil || entry == nil {
+		return nil, err
+	}
+
+	l.Warn("Denied fund request",
+		zap.String("logType", "denied"),
+		zap.String("address_to", request.Address),
+		zap.String("denylist_entry", entry.String()),
+		zap.String("denylist_entry_kind", entry.Kind),
+		zap.String("denylist_entry_source", entry.Source),
+		zap.String("identity_provider", claims.Provider),
+		zap.String("identity_username", claims.Username),
+		zap.String("ip", ip),
+	)
+	return entry, nil
+}
+
+func (s *Server) verifyPoWRequestFund(r *http.Request, request *requestFund) error {
+	ip, err := s.clientIP(r)
+	if err != nil {
+		return err
+	}
+	return s.pow.Verify(r.Context(), request.PoWChallenge, request.PoWNonce, ip)
+}
+
+func (s *Server) verifyCaptchaRequestFund(r *http.Request, request *requestFund) error {
+	ip, err := s.clientIP(r)
+	if err != nil {
+		return err
+	}
+	return s.captcha.Verify(r.Context(), request.CaptchaToken, ip)
+}
+
+// amountRequestFund returns the amount of wei the user asked for (if the
+// identity's policy lets them choose), or the policy's payout.
+func (s *Server) amountRequestFund(
+	cfg *config.Config,
+	claims *jwtFund,
+	key *apikey.Key,
+	request *requestFund,
+) (
+	*big.Int, error,
+) {
+	decimals := cfg.Chain.TokenDecimals
+	policy := cfg.Faucet.PolicyFor(claims.Provider, claims.Tier)
+
+	if request.Amount == "" {
+		if key != nil && key.Payout != "" {
+			policy.Payout = key.Payout
+		}
+		return policy.PayoutWei(decimals)
+	}
+
+	_min, _max, err := policy.PayoutRange(decimals)
+	if err != nil {
+		return nil, err
+	}
+	if _max == nil {
+		return nil, fmt.Errorf("%w: the amount can not be chosen", ErrRequestAmountInvalid)
+	}
+	amount, err := units.Parse(request.Amount, decimals)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRequestAmountInvalid, err)
+	}
+	if amount.Cmp(_min) < 0 || amount.Cmp(_max) > 0 {
+		return nil, fmt.Errorf("%w: must be between %s and %s %s", ErrRequestAmountInvalid,
+			units.Format(_min, decimals), units.Format(_max, decimals), cfg.Chain.TokenSymbol,
+		)
+	}
+	return amount, nil
 }
 
 func (s *Server) ratelimitRequestFund(
 	r *http.Request,
+	cfg *config.Config,
 	claims *jwtFund,
 	request *requestFund,
+	amount *big.Int,
 ) (
 	time.Duration, error,
 ) {
-	forwardedFor := strings.Split(r.Header.Get("x-forwarded-for"), ",")
-	if len(forwardedFor) < s.cfg.Server.ProxyCount {
-		return time.Duration(0), fmt.Errorf("%w: %d", ErrRatelimiterTooFewProxies, len(forwardedFor))
+	policy := cfg.Faucet.PolicyFor(claims.Provider, claims.Tier)
+
+	ip, err := s.clientIP(r)
+	if err != nil {
+		return time.Duration(0), err
+	}
+
+	allowance, err := policy.AllowanceWei(cfg.Chain.TokenDecimals)
+	if err != nil {
+		return time.Duration(0), err
 	}
-	// entryIP := strings.TrimSpace(forwardedFor[len(forwardedFor)-1-s.cfg.Server.ProxyCount])
 
 	ratelimitKeys := map[string]time.Duration{
-		fmt.Sprintf("address:%s", request.Address):                                      max(s.cfg.Faucet.Interval, s.cfg.Faucet.IntervalAddress),
-		fmt.Sprintf("full:%s:%s:%s", claims.Provider, claims.Username, request.Address): max(s.cfg.Faucet.Interval, s.cfg.Faucet.IntervalIdentityAndAddress),
-		fmt.Sprintf("identity:%s:%s", claims.Provider, claims.Username):                 max(s.cfg.Faucet.Interval, s.cfg.Faucet.IntervalIdentity),
-		// fmt.Sprintf("ip:%s", entryIP):                                                   max(s.cfg.Faucet.Interval, s.cfg.Faucet.IntervalIP),
+		fmt.Sprintf("address:%s", request.Address): max(policy.Interval, policy.IntervalAddress),
+		fmt.Sprintf("ip:%s", ip):                   max(policy.Interval, policy.IntervalIP),
+	}
+	if allowance == nil {
+		// otherwise identities are limited by the amount they received
+		ratelimitKeys[fmt.Sprintf("full:%s:%s:%s", claims.Provider, claims.Username, request.Address)] = max(policy.Interval, policy.IntervalIdentityAndAddress)
+		ratelimitKeys[fmt.Sprintf("identity:%s:%s", claims.Provider, claims.Username)] = max(policy.Interval, policy.IntervalIdentity)
 	}
 
 	nextAllowed := time.Now()
@@ -210,10 +543,17 @@ func (s *Server) ratelimitRequestFund(
 		return interval, nil
 	}
 
-	for key, expiry := range ratelimitKeys {
-		if strings.HasPrefix(key, "full:") { // debug
-			expiry = 24 * time.Hour
+	if allowance != nil {
+		wait, err := s.ratelimiter.Charge(r.Context(),
+			fmt.Sprintf("allowance:%s:%s", claims.Provider, claims.Username),
+			amount, allowance, policy.AllowanceWindow,
+		)
+		if err != nil || wait > time.Duration(0) {
+			return wait, err
 		}
+	}
+
+	for key, expiry := range ratelimitKeys {
 		if err := s.ratelimiter.Register(r.Context(), key, expiry); err != nil {
 			return time.Duration(0), err
 		}
@@ -221,3 +561,52 @@ func (s *Server) ratelimitRequestFund(
 
 	return time.Duration(0), nil
 }
+
+func (s *Server) ratelimitRequestFundAPIKey(
+	r *http.Request,
+	key *apikey.Key,
+) (
+	time.Duration, error,
+) {
+	if key.Quota <= 0 {
+		return time.Duration(0), nil
+	}
+
+	count, ttl, err := s.ratelimiter.Increment(r.Context(), "quota:apikey:"+key.ID, key.QuotaWindow)
+	if err != nil {
+		return time.Duration(0), err
+	}
+	if count > key.Quota {
+		return max(ttl, time.Second), nil
+	}
+
+	return time.Duration(0), nil
+}
+
+func (s *Server) recordAPIKeyUsage(
+	r *http.Request,
+	key *apikey.Key,
+	request *requestFund,
+	txHash common.Hash,
+	sendErr error,
+) {
+	ip, _ := s.clientIP(r)
+	usage := &apikey.Usage{
+		Timestamp: time.Now().UTC(),
+		Address:   request.Address,
+		IP:        ip,
+	}
+	if sendErr != nil {
+		usage.Error = sendErr.Error()
+	} else {
+		usage.TxHash = txHash.Hex()
+	}
+
+	if err := s.apikeys.RecordUsage(r.Context(), key.ID, usage); err != nil {
+		l := logutils.LoggerFromRequest(r)
+		l.Error("Failed to record api key usage",
+			zap.Error(err),
+			zap.String("api_key_id", key.ID),
+		)
+	}
+}
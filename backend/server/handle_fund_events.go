@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/txbuilder"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventsKeepAliveInterval keeps the proxies from closing the idle streams
+	eventsKeepAliveInterval = 15 * time.Second
+
+	// eventsMaxDuration is for how long a stream is kept open at most (the
+	// clients reconnect if they still care)
+	eventsMaxDuration = 30 * time.Minute
+)
+
+// handleFundEvents streams the status changes of the payout transaction as
+// server-sent events, until the status is final.
+func (s *Server) handleFundEvents(w http.ResponseWriter, r *http.Request) {
+	l := logutils.LoggerFromRequest(r)
+
+	if s.methodNotAllowed(w, r, http.MethodGet) {
+		return
+	}
+
+	hash, err := hexutil.Decode(r.PathValue("hash"))
+	if err != nil || len(hash) != common.HashLength {
+		s.httpError(w, r, errRequestInvalid.withMessage("Transaction hash is malformed"))
+		return
+	}
+
+	updates, unsubscribe, err := s.txbuilder.Subscribe(r.Context(), common.BytesToHash(hash))
+	if errors.Is(err, txbuilder.ErrTransactionUnknown) {
+		s.httpError(w, r, errNotFound.withMessage("Transaction is unknown"))
+		return
+	}
+	if err != nil {
+		l.Error("Failed to subscribe to transaction status", zap.Error(err))
+		s.httpError(w, r, errRPCUnavailable)
+		return
+	}
+	defer unsubscribe()
+
+	rc := http.NewResponseController(w)
+	// the stream outlives the write timeout of the server
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		l.Warn("Failed to lift the write deadline of the event stream", zap.Error(err))
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("X-Accel-Buffering", "no") // tells nginx not to buffer
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(eventsKeepAliveInterval)
+	defer keepAlive.Stop()
+	timeout := time.NewTimer(eventsMaxDuration)
+	defer timeout.Stop()
+
+	for {
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case status, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(status)
+			if err != nil {
+				l.Error("Failed to marshal transaction status", zap.Error(err))
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", status.State, data); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-timeout.C:
+			return
+		case <-s.shutdown:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
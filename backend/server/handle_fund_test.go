@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/denylist"
+	"github.com/flashbots/eth-faucet/ratelimiter"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatelimitRequestFundAPIKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rl, err := ratelimiter.New(&config.Config{
+		Redis: config.Redis{Timeout: time.Second},
+	}, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	require.NoError(t, err)
+	s := &Server{ratelimiter: rl}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/fund", nil)
+	key := &apikey.Key{ID: "k1", Quota: 2, QuotaWindow: time.Hour}
+	for range key.Quota {
+		wait, err := s.ratelimitRequestFundAPIKey(r, key)
+		require.NoError(t, err)
+		assert.Zero(t, wait)
+	}
+	wait, err := s.ratelimitRequestFundAPIKey(r, key)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, wait)
+
+	// the quota is per key, and renews with the window
+	wait, err = s.ratelimitRequestFundAPIKey(r, &apikey.Key{ID: "k2", Quota: 1, QuotaWindow: time.Hour})
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+	mr.FastForward(time.Hour)
+	wait, err = s.ratelimitRequestFundAPIKey(r, key)
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// unlimited
+	wait, err = s.ratelimitRequestFundAPIKey(r, &apikey.Key{ID: "k3"})
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+}
+
+func TestDenylistRequestFund(t *testing.T) {
+	cfg := &config.Config{
+		Redis:  config.Redis{Timeout: time.Second},
+		Server: config.Server{ProxyCount: 1},
+	}
+	d, err := denylist.New(cfg, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+	entry, err := denylist.ParseEntry("10.0.0.0/8")
+	require.NoError(t, err)
+	require.NoError(t, d.Add(context.Background(), entry))
+	s := &Server{denylist: d}
+	s.cfg.Store(cfg)
+
+	claims := &jwtFund{Provider: "github", Username: "alice"}
+	request := &requestFund{Address: "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/fund", nil)
+	r.Header.Set("X-Forwarded-For", "10.1.2.3")
+	denied, err := s.denylistRequestFund(r, claims, request)
+	require.NoError(t, err)
+	require.NotNil(t, denied)
+	assert.Equal(t, "10.0.0.0/8", denied.Value)
+
+	// the ip entries are not skipped when the ip is unknown
+	r.Header.Del("X-Forwarded-For")
+	_, err = s.denylistRequestFund(r, claims, request)
+	assert.ErrorIs(t, err, ErrRatelimiterTooFewProxies)
+}
+
+func TestRatelimitRequestFundIP(t *testing.T) {
+	cfg := &config.Config{
+		Faucet: config.Faucet{Interval: time.Minute, IntervalIP: time.Hour, Payout: "1"},
+		Redis:  config.Redis{Timeout: time.Second},
+		Server: config.Server{ProxyCount: 1},
+	}
+	rl, err := ratelimiter.New(cfg, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+	s := &Server{ratelimiter: rl}
+	s.cfg.Store(cfg)
+
+	fund := func(ip, username, address string) (time.Duration, error) {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/fund", nil)
+		r.Header.Set("X-Forwarded-For", ip)
+		return s.ratelimitRequestFund(r, cfg,
+			&jwtFund{Provider: "github", Username: username},
+			&requestFund{Address: address},
+			big.NewInt(1),
+		)
+	}
+
+	wait, err := fund("10.0.0.1", "alice", "0x01")
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	// another identity and address, but from the same ip
+	wait, err = fund("10.0.0.1", "bob", "0x02")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, wait, float64(time.Second))
+
+	wait, err = fund("10.0.0.2", "bob", "0x02")
+	require.NoError(t, err)
+	assert.Zero(t, wait)
+
+	_, err = fund("", "carol", "0x03")
+	assert.ErrorIs(t, err, ErrRatelimiterTooFewProxies)
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/units"
+	"go.uber.org/zap"
+)
+
+const (
+	healthStatusFail = "fail"
+	healthStatusOK   = "ok"
+)
+
+const readinessTimeout = 5 * time.Second
+
+var (
+	ErrHealthBalanceTooLow    = errors.New("wallet balance is below the minimum")
+	ErrHealthChainIDMismatch  = errors.New("rpc is on another chain")
+	ErrHealthHeadTooOld       = errors.New("rpc head is too old")
+	ErrHealthRedisUnreachable = errors.New("redis is unreachable")
+	ErrHealthRPCUnreachable   = errors.New("rpc is unreachable")
+)
+
+// healthErrors are the errors that the readiness response may tell.  The
+// causes they wrap stay in the log only, as the probes are public and the
+// causes might expose the rpc url (with its api key) or the redis address.
+var healthErrors = []error{
+	ErrHealthBalanceTooLow,
+	ErrHealthChainIDMismatch,
+	ErrHealthHeadTooOld,
+	ErrHealthRedisUnreachable,
+	ErrHealthRPCUnreachable,
+}
+
+type responseHealth struct {
+	Status string                          `json:"status"`
+	Checks map[string]*responseHealthCheck `json:"checks,omitempty"`
+}
+
+type responseHealthCheck struct {
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Details    map[string]any `json:"details,omitempty"`
+}
+
+type healthCheck func(ctx context.Context) (map[string]any, error)
+
+// handleHealthz tells that the process is alive.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	l := logutils.LoggerFromRequest(r)
+
+	if err := s.renderJSON(w, http.StatusOK, &responseHealth{Status: healthStatusOK}); err != nil {
+		l.Error("Failed to send health response", zap.Error(err))
+	}
+}
+
+// handleReadyz tells whether the faucet can actually pay out, with the
+// breakdown per component.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	l := logutils.LoggerFromRequest(r)
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	res, errs := runHealthChecks(ctx, map[string]healthCheck{
+		"chain_id": s.checkChainID,
+		"redis":    s.checkRedis,
+		"rpc":      s.checkRPCHead,
+		"wallet":   s.checkWalletBalance,
+	})
+
+	code := http.StatusOK
+	if res.Status != healthStatusOK {
+		code = http.StatusServiceUnavailable
+		fields := make([]zap.Field, 0, len(errs))
+		for name, err := range errs {
+			fields = append(fields, zap.NamedError(name, err))
+		}
+		l.Warn("Faucet is not ready", fields...)
+	}
+
+	if err := s.renderJSON(w, code, res); err != nil {
+		l.Error("Failed to send health response", zap.Error(err))
+	}
+}
+
+// runHealthChecks runs the checks concurrently.  It returns the response
+// together with the full errors of the checks that failed (for the log).
+func runHealthChecks(ctx context.Context, checks map[string]healthCheck) (*responseHealth, map[string]error) {
+	res := &responseHealth{
+		Status: healthStatusOK,
+		Checks: make(map[string]*responseHealthCheck, len(checks)),
+	}
+	errs := make(map[string]error)
+	var (
+		mx sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check(ctx)
+			result := &responseHealthCheck{
+				Status:     healthStatusOK,
+				DurationMs: time.Since(start).Milliseconds(),
+				Details:    details,
+			}
+			if err != nil {
+				result.Status, result.Error = healthStatusFail, healthError(err)
+			}
+
+			mx.Lock()
+			defer mx.Unlock()
+			res.Checks[name] = result
+			if err != nil {
+				res.Status = healthStatusFail
+				errs[name] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	return res, errs
+}
+
+// healthError returns the part of the error that is safe to be told.
+func healthError(err error) string {
+	for _, e := range healthErrors {
+		if errors.Is(err, e) {
+			return e.Error()
+		}
+	}
+	return "check failed"
+}
+
+func (s *Server) checkRedis(ctx context.Context) (map[string]any, error) {
+	if err := s.ratelimiter.Ping(ctx); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHealthRedisUnreachable, err)
+	}
+	return nil, nil
+}
+
+func (s *Server) checkRPCHead(ctx context.Context) (map[string]any, error) {
+	maxAge := s.config().Health.MaxHeadAge
+
+	head, err := s.txbuilder.Head(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHealthRPCUnreachable, err)
+	}
+	age := time.Since(time.Unix(int64(head.Time), 0)).Round(time.Second)
+	details := map[string]any{
+		"head":     head.Number.String(),
+		"head_age": age.String(),
+	}
+	if maxAge > 0 && age > maxAge {
+		return details, fmt.Errorf("%w: %s (max %s)", ErrHealthHeadTooOld, age, maxAge)
+	}
+	return details, nil
+}
+
+// checkChainID makes sure the rpc is still on the chain the faucet signs the
+// transactions for (and on the configured one, if any).
+func (s *Server) checkChainID(ctx context.Context) (map[string]any, error) {
+	expected := s.txbuilder.ChainID()
+	if id := s.config().Chain.ID; id != 0 && expected.Uint64() != id {
+		return map[string]any{"expected": id, "signer": expected.String()},
+			fmt.Errorf("%w: signing for %s instead of %d", ErrHealthChainIDMismatch, expected, id)
+	}
+
+	actual, err := s.txbuilder.RPCChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHealthRPCUnreachable, err)
+	}
+	details := map[string]any{"chain_id": actual.String()}
+	if actual.Cmp(expected) != 0 {
+		return details, fmt.Errorf("%w: %s instead of %s", ErrHealthChainIDMismatch, actual, expected)
+	}
+	return details, nil
+}
+
+func (s *Server) checkWalletBalance(ctx context.Context) (map[string]any, error) {
+	cfg := s.config()
+	decimals := cfg.Chain.TokenDecimals
+
+	minimum, err := cfg.Health.MinBalanceWei(cfg.Faucet, decimals)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.txbuilder.Balance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHealthRPCUnreachable, err)
+	}
+	details := map[string]any{
+		"balance":     units.Format(balance, decimals),
+		"min_balance": units.Format(minimum, decimals),
+	}
+	if balance.Cmp(minimum) < 0 {
+		return details, fmt.Errorf("%w: %s %s (min %s)", ErrHealthBalanceTooLow,
+			units.Format(balance, decimals), cfg.Chain.TokenSymbol, units.Format(minimum, decimals),
+		)
+	}
+	return details, nil
+}
@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleHealthz(t *testing.T) {
+	w := httptest.NewRecorder()
+	(&Server{}).handleHealthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	res := &responseHealth{}
+	require.NoError(t, json.NewDecoder(w.Body).Decode(res))
+	assert.Equal(t, healthStatusOK, res.Status)
+}
+
+func TestRunHealthChecks(t *testing.T) {
+	leaky := errors.New(`Post "https://mainnet.example.com/v3/secret-api-key": dial tcp: connection refused`)
+
+	res, errs := runHealthChecks(context.Background(), map[string]healthCheck{
+		"ok": func(context.Context) (map[string]any, error) {
+			return map[string]any{"head": "1"}, nil
+		},
+		"rpc": func(context.Context) (map[string]any, error) {
+			return nil, fmt.Errorf("%w: %w", ErrHealthRPCUnreachable, leaky)
+		},
+		"other": func(context.Context) (map[string]any, error) {
+			return nil, leaky
+		},
+	})
+
+	assert.Equal(t, healthStatusFail, res.Status)
+	assert.Equal(t, &responseHealthCheck{Status: healthStatusOK, Details: map[string]any{"head": "1"}}, res.Checks["ok"])
+	assert.Equal(t, healthStatusFail, res.Checks["rpc"].Status)
+	assert.Equal(t, "rpc is unreachable", res.Checks["rpc"].Error)
+	assert.Equal(t, "check failed", res.Checks["other"].Error)
+
+	// the causes are kept for the log
+	assert.Len(t, errs, 2)
+	assert.ErrorIs(t, errs["rpc"], leaky)
+
+	body, err := json.Marshal(res)
+	require.NoError(t, err)
+	assert.NotContains(t, string(body), "secret-api-key")
+}
+
+func TestHealthError(t *testing.T) {
+	for err, expected := range map[error]string{
+		fmt.Errorf("%w: 0.5 ETH (min 1)", ErrHealthBalanceTooLow):               "wallet balance is below the minimum",
+		fmt.Errorf("%w: 5 instead of 1337", ErrHealthChainIDMismatch):           "rpc is on another chain",
+		fmt.Errorf("%w: 5m0s (max 2m0s)", ErrHealthHeadTooOld):                  "rpc head is too old",
+		fmt.Errorf("%w: %w", ErrHealthRedisUnreachable, errors.New("10.0.0.1")): "redis is unreachable",
+		errors.New("10.0.0.1"): "check failed",
+	} {
+		assert.Equal(t, expected, healthError(err), err.Error())
+	}
+}
@@ -2,33 +2,65 @@ package server
 
 import (
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/units"
 	"go.uber.org/zap"
 )
 
 type responseInfo struct {
-	Address string `json:"address"`
-	Chain   string `json:"network"`
-	Payout  string `json:"payout"`
-	Symbol  string `json:"symbol"`
+	Address   string `json:"address"`
+	Chain     string `json:"network"`
+	Payout    string `json:"payout"`
+	PayoutWei string `json:"payout_wei"`
+	Symbol    string `json:"symbol"`
+
+	// the bounds of the amount the user can choose (if they can)
+	PayoutMax string `json:"payout_max,omitempty"`
+	PayoutMin string `json:"payout_min,omitempty"`
+
+	Maintenance *responseMaintenance `json:"maintenance"`
 }
 
 func (s *Server) handleInfo(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.httpError(w, http.StatusNotImplemented)
+	if s.methodNotAllowed(w, r, http.MethodGet) {
+		return
+	}
+
+	l := logutils.LoggerFromRequest(r)
+	cfg := s.config()
+
+	payout, err := cfg.Faucet.PayoutWei(cfg.Chain.TokenDecimals)
+	if err != nil {
+		l.Error("Failed to determine payout amount", zap.Error(err))
+		s.httpError(w, r, errInternal)
 		return
 	}
 
-	err := s.renderJSON(w, http.StatusOK, responseInfo{
-		Address: s.txbuilder.Address(),
-		Chain:   s.cfg.Chain.Name,
-		Payout:  strconv.FormatInt(s.cfg.Faucet.Payout, 10),
-		Symbol:  s.cfg.Chain.TokenSymbol,
-	})
+	_min, _max, err := cfg.Faucet.PayoutRange(cfg.Chain.TokenDecimals)
+	if err != nil {
+		l.Error("Failed to determine payout range", zap.Error(err))
+		s.httpError(w, r, errInternal)
+		return
+	}
+
+	info := responseInfo{
+		Address:   s.txbuilder.Address(),
+		Chain:     cfg.Chain.Name,
+		Payout:    units.Format(payout, cfg.Chain.TokenDecimals),
+		PayoutWei: payout.String(),
+		Symbol:    cfg.Chain.TokenSymbol,
+
+		Maintenance: newResponseMaintenance(s.maintenance.Status(time.Now())),
+	}
+	if _max != nil {
+		info.PayoutMax = units.Format(_max, cfg.Chain.TokenDecimals)
+		info.PayoutMin = units.Format(_min, cfg.Chain.TokenDecimals)
+	}
+
+	err = s.renderJSON(w, http.StatusOK, info)
 	if err != nil {
-		l := logutils.LoggerFromRequest(r)
 		l.Error("Failed to send info response", zap.Error(err))
 	}
 }
@@ -0,0 +1,27 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+// openAPI is the specification of the api.  It is maintained by hand, and the
+// tests check the responses of the handlers against it.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if s.methodNotAllowed(w, r, http.MethodGet) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(openAPI); err != nil {
+		l := logutils.LoggerFromRequest(r)
+		l.Error("Failed to send openapi response", zap.Error(err))
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	providerSIWE = "siwe"
+)
+
+var (
+	ErrSIWESignatureMalformed = errors.New("siwe signature is malformed")
+)
+
+type requestSIWEVerify struct {
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+type responseSIWENonce struct {
+	Nonce string `json:"nonce"`
+}
+
+type responseSIWEVerify struct {
+	Address   string    `json:"address"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Token     string    `json:"token"`
+}
+
+func (s *Server) handleSIWENonce(w http.ResponseWriter, r *http.Request) {
+	l := logutils.LoggerFromRequest(r)
+
+	if s.methodNotAllowed(w, r, http.MethodGet) {
+		return
+	}
+
+	nonce, err := s.siwe.Nonce(r.Context())
+	if err != nil {
+		l.Error("Failed to issue siwe nonce", zap.Error(err))
+		s.httpError(w, r, errServiceUnavailable)
+		return
+	}
+
+	err = s.renderJSON(w, http.StatusOK, &responseSIWENonce{
+		Nonce: nonce,
+	})
+	if err != nil {
+		l.Error("Failed to send siwe nonce response", zap.Error(err))
+	}
+}
+
+func (s *Server) handleSIWEVerify(w http.ResponseWriter, r *http.Request) {
+	cfg := s.config()
+	l := logutils.LoggerFromRequest(r)
+
+	if s.methodNotAllowed(w, r, http.MethodPost) {
+		return
+	}
+
+	request := &requestSIWEVerify{}
+	if err := s.parseRequest(r, request); err != nil {
+		l.Warn("Failed to parse siwe verify request", zap.Error(err))
+		s.httpError(w, r, errRequestInvalid)
+		return
+	}
+	signature, err := hexutil.Decode(request.Signature)
+	if err != nil {
+		l.Warn("Failed to parse siwe verify request", zap.Error(errors.Join(ErrSIWESignatureMalformed, err)))
+		s.httpError(w, r, errRequestInvalid.withMessage("Signature is malformed"))
+		return
+	}
+
+	message, err := s.siwe.Verify(r.Context(), request.Message, signature)
+	if err != nil {
+		l.Warn("Failed to verify siwe message", zap.Error(err))
+		s.httpError(w, r, errAuthInvalid.withMessage("Signed message is invalid"))
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(cfg.SIWE.SessionTTL)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwtFund{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Provider: providerSIWE,
+		Username: message.Address.Hex(),
+	}).SignedString([]byte(cfg.Server.AuthSecret))
+	if err != nil {
+		l.Error("Failed to sign siwe session token", zap.Error(err))
+		s.httpError(w, r, errInternal)
+		return
+	}
+
+	l.Info("Verified siwe message",
+		zap.String("identity_provider", providerSIWE),
+		zap.String("identity_username", message.Address.Hex()),
+	)
+
+	err = s.renderJSON(w, http.StatusOK, &responseSIWEVerify{
+		Address:   message.Address.Hex(),
+		ExpiresAt: expiresAt.UTC(),
+		Token:     token,
+	})
+	if err != nil {
+		l.Error("Failed to send siwe verify response", zap.Error(err))
+	}
+}
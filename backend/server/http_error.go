@@ -1,11 +1,108 @@
 package server
 
-import "net/http"
-
-func (s *Server) httpError(w http.ResponseWriter, httpError int) {
-	http.Error(
-		w,
-		http.StatusText(httpError),
-		httpError,
-	)
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/txbuilder"
+	"go.uber.org/zap"
+)
+
+// apiError is the error the api responds with.  The codes are stable (the
+// clients are expected to act on them), the messages are for the humans and
+// never carry the internal details (those are logged instead).
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+var (
+	errAddressInvalid = apiError{http.StatusBadRequest, "invalid_address", "Address is not a valid hex address"}
+	errAmountInvalid  = apiError{http.StatusBadRequest, "invalid_amount", "Amount is invalid"}
+	errRequestInvalid = apiError{http.StatusBadRequest, "invalid_request", "Request is malformed"}
+
+	errAuthExpired  = apiError{http.StatusUnauthorized, "auth_expired", "Your api token is expired, please try refreshing the page"}
+	errAuthInvalid  = apiError{http.StatusUnauthorized, "auth_invalid", "Credentials are invalid"}
+	errAuthRequired = apiError{http.StatusUnauthorized, "auth_required", "Authorization is required"}
+
+	errAddressNotAllowed = apiError{http.StatusForbidden, "address_not_allowed", "Address is not allowed for the api key"}
+	errCaptchaFailed     = apiError{http.StatusForbidden, "captcha_failed", "Captcha verification failed"}
+	errDenied            = apiError{http.StatusForbidden, "denied", "Request is denied"}
+	errIneligible        = apiError{http.StatusForbidden, "ineligible", "Your account does not qualify for the faucet"}
+	errPoWFailed         = apiError{http.StatusForbidden, "pow_failed", "Proof-of-work verification failed"}
+
+	errNotFound                 = apiError{http.StatusNotFound, "not_found", "Not found"}
+	errMethodNotAllowed         = apiError{http.StatusMethodNotAllowed, "method_not_allowed", "Method is not allowed"}
+	errIdempotencyKeyInProgress = apiError{http.StatusConflict, "idempotency_key_in_progress", "Request with the same idempotency key is still in progress"}
+	errIdempotencyKeyMismatch   = apiError{http.StatusUnprocessableEntity, "idempotency_key_mismatch", "Idempotency key is already used for a different request"}
+	errRateLimited              = apiError{http.StatusTooManyRequests, "rate_limited", "Too many requests"}
+
+	errInternal                  = apiError{http.StatusInternalServerError, "internal_error", "Internal error"}
+	errInsufficientFaucetBalance = apiError{http.StatusServiceUnavailable, "insufficient_faucet_balance", "Faucet is out of funds, please try again later"}
+	errMaintenance               = apiError{http.StatusServiceUnavailable, "maintenance", "Faucet is under maintenance"}
+	errRPCUnavailable            = apiError{http.StatusServiceUnavailable, "rpc_unavailable", "Network is unavailable, please try again later"}
+	errServiceUnavailable        = apiError{http.StatusServiceUnavailable, "service_unavailable", "Service is unavailable, please try again later"}
+)
+
+// withMessage returns the copy of the error with the more specific message.
+func (e apiError) withMessage(message string) apiError {
+	e.message = message
+	return e
+}
+
+type responseError struct {
+	Code      string     `json:"code"`
+	Message   string     `json:"message"`
+	RequestID string     `json:"request_id"`
+	ResumeAt  *time.Time `json:"resume_at,omitempty"`
+}
+
+func (s *Server) httpError(w http.ResponseWriter, r *http.Request, e apiError) {
+	s.renderError(w, r, e.status, &responseError{
+		Code:    e.code,
+		Message: e.message,
+	})
+}
+
+func (s *Server) renderError(w http.ResponseWriter, r *http.Request, status int, res *responseError) {
+	res.RequestID = httplogger.RequestID(r)
+	if err := s.renderJSON(w, status, res); err != nil {
+		l := logutils.LoggerFromRequest(r)
+		l.Error("Failed to send error response", zap.Error(err))
+	}
+}
+
+func (s *Server) handleNotFound(w http.ResponseWriter, r *http.Request) {
+	s.httpError(w, r, errNotFound)
+}
+
+// methodNotAllowed responds with 405 unless the request has the method.
+func (s *Server) methodNotAllowed(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return false
+	}
+	w.Header().Set("Allow", method)
+	s.httpError(w, r, errMethodNotAllowed)
+	return true
+}
+
+// sendFundsError tells the client why the transaction could not be sent.
+func sendFundsError(err error) apiError {
+	var rpcErr rpc.Error
+	switch {
+	case errors.Is(err, txbuilder.ErrInsufficientFunds):
+		return errInsufficientFaucetBalance
+	case errors.Is(err, txbuilder.ErrFailedToSuggestGasPrice):
+		return errRPCUnavailable
+	case errors.Is(err, txbuilder.ErrFailedToSendTransaction) && !errors.As(err, &rpcErr):
+		// the rpc did not respond (as opposed to rejecting the transaction)
+		return errRPCUnavailable
+	default:
+		return errInternal.withMessage("Failed to send the transaction")
+	}
 }
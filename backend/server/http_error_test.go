@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/txbuilder"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rpcError struct{}
+
+func (rpcError) Error() string  { return "transaction underpriced" }
+func (rpcError) ErrorCode() int { return -32000 }
+
+var _ rpc.Error = rpcError{}
+
+func TestSendFundsError(t *testing.T) {
+	for err, expected := range map[error]string{
+		fmt.Errorf("%w: %w", txbuilder.ErrFailedToSendTransaction,
+			fmt.Errorf("%w: insufficient funds for gas * price + value", txbuilder.ErrInsufficientFunds),
+		): "insufficient_faucet_balance",
+		fmt.Errorf("%w: %w", txbuilder.ErrFailedToSuggestGasPrice, errors.New("dial tcp: connection refused")): "rpc_unavailable",
+		fmt.Errorf("%w: %w", txbuilder.ErrFailedToSendTransaction, errors.New("dial tcp: connection refused")): "rpc_unavailable",
+		fmt.Errorf("%w: %w", txbuilder.ErrFailedToSendTransaction, rpcError{}):                                 "internal_error",
+	} {
+		assert.Equal(t, expected, sendFundsError(err).code, err.Error())
+	}
+}
+
+func TestAuthoriseError(t *testing.T) {
+	for err, expected := range map[error]string{
+		fmt.Errorf("%w: %w", ErrJWTFailedToParse, jwt.ErrTokenExpired):     "auth_expired",
+		fmt.Errorf("%w: %w", ErrJWTFailedToParse, jwt.ErrSignatureInvalid): "auth_invalid",
+		ErrAuthorisationHeaderMissing:                                      "auth_required",
+		fmt.Errorf("%w: %s", apikey.ErrKeyNotFound, "abc"):                 "auth_invalid",
+		errors.New("redis: connection refused"):                            "service_unavailable",
+	} {
+		assert.Equal(t, expected, authoriseError(err).code, err.Error())
+	}
+}
+
+func TestHTTPError(t *testing.T) {
+	s := &Server{}
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/api/fund", nil)
+
+	require.True(t, s.methodNotAllowed(w, r, http.MethodPost))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+
+	res := map[string]any{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "method_not_allowed", res["code"])
+	assert.Equal(t, "Method is not allowed", res["message"])
+	assert.Contains(t, res, "request_id")
+}
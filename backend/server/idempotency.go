@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/flashbots/eth-faucet/idempotency"
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+const (
+	headerIdempotencyKey     = "Idempotency-Key"
+	headerIdempotentReplayed = "Idempotent-Replayed"
+
+	idempotencyKeyMaxLength = 255
+
+	// idempotencyWait is for how long the duplicates wait for the first
+	// request to finish (less than the write timeout of the server)
+	idempotencyWait = 25 * time.Second
+)
+
+// responseRecorder passes the response through, keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+
+	body   bytes.Buffer
+	status int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	rr.status = status
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// idempotentRequestFund processes the request with the idempotency key only
+// once (per identity), and replays its response to the retries.  The
+// transient failures (rate-limited or 5xx) are not stored, so that the retries
+// of those are processed anew.
+func (s *Server) idempotentRequestFund(
+	w http.ResponseWriter,
+	r *http.Request,
+	claims *jwtFund,
+	request *requestFund,
+	idempotencyKey string,
+	next func(w http.ResponseWriter),
+) {
+	l := logutils.LoggerFromRequest(r)
+
+	if len(idempotencyKey) > idempotencyKeyMaxLength {
+		s.httpError(w, r, errRequestInvalid.withMessage("Idempotency key is too long"))
+		return
+	}
+
+	// the parsed request, so that the formatting of the body does not matter
+	fingerprint, err := json.Marshal(request)
+	if err != nil {
+		l.Error("Failed to fingerprint fund request", zap.Error(err))
+		s.httpError(w, r, errInternal)
+		return
+	}
+	scope := claims.Provider + ":" + claims.Username
+
+	ctx, cancel := context.WithTimeout(r.Context(), idempotencyWait)
+	defer cancel()
+	lock, stored, err := s.idempotency.Begin(ctx, scope, idempotencyKey, string(fingerprint))
+	switch {
+	case errors.Is(err, idempotency.ErrKeyMismatch):
+		s.httpError(w, r, errIdempotencyKeyMismatch)
+		return
+	case errors.Is(err, idempotency.ErrKeyInProgress):
+		w.Header().Set("Retry-After", "1")
+		s.httpError(w, r, errIdempotencyKeyInProgress)
+		return
+	case err != nil:
+		l.Error("Failed to check idempotency key", zap.Error(err))
+		s.httpError(w, r, errServiceUnavailable)
+		return
+	case stored != nil:
+		l.Info("Replaying fund response",
+			zap.String("identity_provider", claims.Provider),
+			zap.String("identity_username", claims.Username),
+			zap.Int("status", stored.Status),
+		)
+		for name, value := range stored.Header {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set(headerIdempotentReplayed, "true")
+		w.WriteHeader(stored.Status)
+		if _, err := w.Write(stored.Body); err != nil {
+			l.Error("Failed to send replayed fund response", zap.Error(err))
+		}
+		return
+	}
+
+	rec := &responseRecorder{ResponseWriter: w}
+	defer func() {
+		// the claim must not be extended forever
+		if p := recover(); p != nil {
+			_ = lock.Release(context.WithoutCancel(r.Context()))
+			panic(p)
+		}
+	}()
+	next(rec)
+
+	// stored even if the client has gone away (that is when the retry is
+	// most likely to come)
+	ctx = context.WithoutCancel(r.Context())
+	if rec.status == http.StatusTooManyRequests || rec.status >= http.StatusInternalServerError {
+		if err := lock.Release(ctx); err != nil {
+			l.Error("Failed to release idempotency key", zap.Error(err))
+		}
+		return
+	}
+	err = lock.Complete(ctx, &idempotency.Response{
+		Fingerprint: string(fingerprint),
+		Status:      rec.status,
+		Header:      map[string]string{"Content-Type": w.Header().Get("Content-Type")},
+		Body:        rec.body.Bytes(),
+	})
+	if err != nil {
+		l.Error("Failed to store fund response for idempotency key", zap.Error(err))
+	}
+}
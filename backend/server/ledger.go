@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/ledger"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/txbuilder"
+	"github.com/flashbots/eth-faucet/webhook"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// fundOutcomeTimeout is for how long the outcome of the payout is awaited
+const fundOutcomeTimeout = time.Hour
+
+// newLedgerEntry starts the record of the fund attempt.  Unless told
+// otherwise, the attempt counts as failed.
+func (s *Server) newLedgerEntry(
+	r *http.Request, claims *jwtFund, key *apikey.Key, request *requestFund,
+) *ledger.Entry {
+	ip, _ := s.clientIP(r)
+	entry := &ledger.Entry{
+		AttemptID: uuid.Must(uuid.NewRandom()).String(),
+		CreatedAt: time.Now().UTC(),
+
+		Provider: claims.Provider,
+		Username: claims.Username,
+		Address:  request.Address,
+		IP:       ip,
+		Status:   ledger.StatusFailed,
+		Sender:   s.txbuilder.Address(),
+	}
+	if key != nil {
+		entry.APIKeyID = key.ID
+	}
+	return entry
+}
+
+// recordFundAttempt accounts for the outcome of the attempt in the metrics
+// and in the ledger.  The outcome of the sent payout is tracked further on.
+func (s *Server) recordFundAttempt(r *http.Request, entry *ledger.Entry) {
+	metrics.RecordFund(entry.Status)
+	if entry.Status == ledger.StatusSent && entry.Amount != nil {
+		metrics.RecordPayout(entry.Amount)
+	}
+
+	if s.ledger != nil {
+		// the attempt is recorded even if the client has gone away
+		ctx := context.WithoutCancel(r.Context())
+		if err := s.ledger.Record(ctx, entry); err != nil {
+			l := logutils.LoggerFromRequest(r)
+			l.Error("Failed to record fund attempt in the ledger",
+				zap.Error(err),
+				zap.String("status", entry.Status),
+				zap.String("tx_hash", entry.TxHash),
+			)
+		}
+	}
+
+	if entry.Status == ledger.StatusSent {
+		s.trackFundOutcome(r, *entry)
+	}
+}
+
+// trackFundOutcome waits for the sent transaction to be confirmed (or to
+// fail), and then updates the ledger and notifies the webhooks.
+func (s *Server) trackFundOutcome(r *http.Request, entry ledger.Entry) {
+	if s.webhooks == nil && (s.ledger == nil || entry.ID == 0) {
+		return
+	}
+
+	data := s.webhookFund(r, &entry)
+	s.outcomes.Add(1)
+	go func() {
+		defer s.outcomes.Done()
+
+		// the outcome is awaited after the response is sent, but not forever
+		// (nor past the shutdown, which waits for it)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), fundOutcomeTimeout)
+		defer cancel()
+		l := logutils.LoggerFromContext(ctx)
+
+		updates, unsubscribe, err := s.txbuilder.Subscribe(ctx, common.HexToHash(entry.TxHash))
+		if err != nil {
+			l.Warn("Failed to subscribe to transaction status",
+				zap.Error(err),
+				zap.String("tx_hash", entry.TxHash),
+			)
+			return
+		}
+		defer unsubscribe()
+
+		var status txbuilder.Status
+	wait:
+		for {
+			select {
+			case update, ok := <-updates:
+				if !ok {
+					break wait
+				}
+				status = update
+			case <-ctx.Done():
+				l.Warn("Gave up waiting for the transaction outcome",
+					zap.String("tx_hash", entry.TxHash),
+				)
+				return
+			case <-s.shutdown:
+				l.Warn("Stopped waiting for the transaction outcome due to shutdown",
+					zap.String("tx_hash", entry.TxHash),
+				)
+				return
+			}
+		}
+
+		event := webhook.EventFundFailed
+		switch status.State {
+		case txbuilder.StateConfirmed:
+			entry.Status, event = ledger.StatusConfirmed, webhook.EventFundConfirmed
+		case txbuilder.StateFailed:
+			entry.Status, entry.Error = ledger.StatusReverted, "transaction reverted"
+		case txbuilder.StateReplaced:
+			entry.Status, entry.Error = ledger.StatusReplaced, "transaction replaced"
+		default:
+			return
+		}
+
+		if s.ledger != nil && entry.ID != 0 {
+			if err := s.ledger.Update(ctx, &entry); err != nil {
+				l.Error("Failed to update fund attempt in the ledger",
+					zap.Error(err),
+					zap.String("status", entry.Status),
+					zap.String("tx_hash", entry.TxHash),
+				)
+			}
+		}
+		if s.webhooks != nil {
+			data.BlockNumber, data.Error = status.BlockNumber, entry.Error
+			s.webhooks.Send(ctx, event, data)
+		}
+	}()
+}
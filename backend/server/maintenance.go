@@ -0,0 +1,54 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/maintenance"
+	"go.uber.org/zap"
+)
+
+type responseMaintenance struct {
+	Active   bool       `json:"active"`
+	Message  string     `json:"message,omitempty"`
+	Reason   string     `json:"reason,omitempty"`
+	ResumeAt *time.Time `json:"resume_at,omitempty"`
+}
+
+func newResponseMaintenance(status maintenance.Status) *responseMaintenance {
+	res := &responseMaintenance{
+		Active:  status.Active,
+		Message: status.Message,
+		Reason:  status.Reason,
+	}
+	if !status.ResumeAt.IsZero() {
+		resumeAt := status.ResumeAt.UTC()
+		res.ResumeAt = &resumeAt
+	}
+	return res
+}
+
+// renderMaintenance refuses the request with 503 and tells when to come back.
+func (s *Server) renderMaintenance(w http.ResponseWriter, r *http.Request, status maintenance.Status) {
+	l := logutils.LoggerFromRequest(r)
+
+	res := &responseError{
+		Code:    errMaintenance.code,
+		Message: status.Message + ", please come back later",
+	}
+	if !status.ResumeAt.IsZero() {
+		resumeAt := status.ResumeAt.UTC()
+		res.Message = status.Message + ", please come back at " + resumeAt.Format(time.RFC3339)
+		res.ResumeAt = &resumeAt
+
+		retryAfter := int(time.Until(resumeAt).Round(time.Second).Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	}
+
+	l.Info("Refused fund request due to maintenance",
+		zap.String("reason", status.Reason),
+	)
+	s.renderError(w, r, errMaintenance.status, res)
+}
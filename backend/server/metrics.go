@@ -0,0 +1,39 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/flashbots/eth-faucet/metrics"
+	"go.uber.org/zap"
+)
+
+func (s *Server) metricsServer() *http.Server {
+	cfg := s.config()
+
+	metrics.RegisterWallet(s.txbuilder, cfg.RPC.Timeout)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	return &http.Server{
+		Addr:              cfg.Metrics.ListenAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: 30 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+}
+
+func (s *Server) runMetricsServer(srv *http.Server) {
+	l := s.log
+
+	l.Info("Starting up metrics server...",
+		zap.String("metrics_listen_address", srv.Addr),
+	)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		l.Error("Metrics server failed", zap.Error(err))
+	}
+}
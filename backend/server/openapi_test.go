@@ -0,0 +1,250 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// rpcStub answers the json-rpc calls the faucet makes with canned results.
+func rpcStub(t *testing.T) *httptest.Server {
+	results := map[string]any{
+		"eth_chainId":              "0x539",
+		"eth_gasPrice":             "0x3b9aca00",
+		"eth_getTransactionByHash": nil,
+		"eth_getTransactionCount":  "0x0",
+		"eth_sendRawTransaction":   "0x" + strings.Repeat("ab", 32),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response := map[string]any{"jsonrpc": "2.0", "id": request.ID}
+		if result, ok := results[request.Method]; ok {
+			response["result"] = result
+		} else {
+			response["error"] = map[string]any{"code": -32601, "message": "method not found"}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAPI(t *testing.T) {
+	ctx := context.Background()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openAPI)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(ctx))
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		Chain:  config.Chain{Name: "testnet", TokenDecimals: 18, TokenSymbol: "tEth"},
+		Faucet: config.Faucet{Interval: time.Hour, Payout: "1"},
+		PoW:    config.PoW{Difficulty: 1, DifficultyMax: 1, Enabled: true, Secret: "secret", TTL: time.Minute, Window: time.Minute},
+		Redis:  config.Redis{URL: "redis://" + miniredis.RunT(t).Addr(), Timeout: time.Second},
+		RPC:    config.RPC{Endpoints: []string{rpcStub(t).URL}, Timeout: time.Second},
+		Server: config.Server{AuthSecret: "secret", IdempotencyWindow: time.Hour, MaxRequestBodySize: 1024},
+		Wallet: config.Wallet{PrivateKey: "91ab9a7e53c220e6210460b65a7a3bb2ca181412a8a7b43ff336b3df1737ce12"},
+	}
+	s, err := New(cfg)
+	require.NoError(t, err)
+	handler := httplogger.Middleware(zap.NewNop(), s.api())
+
+	sign := func(username string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwtFund{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			Provider:         "github",
+			Username:         username,
+		}).SignedString([]byte(cfg.Server.AuthSecret))
+		require.NoError(t, err)
+		return token
+	}
+	token := sign("alice")
+
+	for _, tc := range []struct {
+		name   string
+		method string // of the operation in the spec (if differs)
+		r      *http.Request
+		status int
+		code   string
+
+		replayed bool
+	}{
+		{
+			name:   "info",
+			r:      httptest.NewRequest(http.MethodGet, "/api/v1/info", nil),
+			status: http.StatusOK,
+		},
+		{
+			name:   "info (unversioned)",
+			r:      httptest.NewRequest(http.MethodGet, "/api/info", nil),
+			status: http.StatusOK,
+		},
+		{
+			name:   "openapi",
+			r:      httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil),
+			status: http.StatusOK,
+		},
+		{
+			name:   "challenge",
+			r:      httptest.NewRequest(http.MethodGet, "/api/v1/challenge", nil),
+			status: http.StatusOK,
+		},
+		{
+			name:   "fund",
+			r:      fundRequest(token, `{"address": "0x00000000000000000000000000000000000000a1"}`),
+			status: http.StatusOK,
+		},
+		{
+			name:   "fund (rate-limited)",
+			r:      fundRequest(token, `{"address": "0x00000000000000000000000000000000000000a1"}`),
+			status: http.StatusTooManyRequests,
+			code:   "rate_limited",
+		},
+		{
+			name:   "fund (idempotent)",
+			r:      idempotent(fromIP(fundRequest(sign("bob"), `{"address": "0x00000000000000000000000000000000000000a3"}`), "192.0.2.2"), "k1"),
+			status: http.StatusOK,
+		},
+		{
+			name:     "fund (replayed)",
+			r:        idempotent(fromIP(fundRequest(sign("bob"), `{"address": "0x00000000000000000000000000000000000000a3"}`), "192.0.2.2"), "k1"),
+			status:   http.StatusOK,
+			replayed: true,
+		},
+		{
+			name:   "fund (idempotency key reused)",
+			r:      idempotent(fromIP(fundRequest(sign("bob"), `{"address": "0x00000000000000000000000000000000000000a4"}`), "192.0.2.2"), "k1"),
+			status: http.StatusUnprocessableEntity,
+			code:   "idempotency_key_mismatch",
+		},
+		{
+			name:   "fund (invalid amount)",
+			r:      fundRequest(token, `{"address": "0x00000000000000000000000000000000000000a2", "amount": "2"}`),
+			status: http.StatusBadRequest,
+			code:   "invalid_amount",
+		},
+		{
+			name:   "fund (unauthorised)",
+			r:      fundRequest("garbage", `{"address": "0x00000000000000000000000000000000000000a2"}`),
+			status: http.StatusUnauthorized,
+			code:   "auth_invalid",
+		},
+		{
+			name:   "fund (without pow solution)",
+			r:      fundRequest("", `{"address": "0x00000000000000000000000000000000000000a2"}`),
+			status: http.StatusForbidden,
+			code:   "pow_failed",
+		},
+		{
+			name:   "fund events (unknown transaction)",
+			r:      httptest.NewRequest(http.MethodGet, "/api/v1/fund/0x"+strings.Repeat("cd", 32)+"/events", nil),
+			status: http.StatusNotFound,
+			code:   "not_found",
+		},
+		{
+			name:   "fund (wrong method)",
+			method: http.MethodPost,
+			r:      httptest.NewRequest(http.MethodGet, "/api/v1/fund", nil),
+			status: http.StatusMethodNotAllowed,
+			code:   "method_not_allowed",
+		},
+	} {
+		var body []byte
+		if tc.r.Body != nil {
+			body, err = io.ReadAll(tc.r.Body)
+			require.NoError(t, err)
+		}
+		tc.r.Body = io.NopCloser(bytes.NewReader(body))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, tc.r)
+		require.Equal(t, tc.status, w.Code, "%s: %s", tc.name, w.Body.String())
+		assert.Equal(t, tc.replayed, w.Header().Get(headerIdempotentReplayed) == "true", tc.name)
+
+		// the response is checked against the operation of the versioned path
+		method := tc.r.Method
+		if tc.method != "" {
+			method = tc.method
+		}
+		path := tc.r.URL.Path
+		if !strings.HasPrefix(path, "/api/v1/") {
+			path = "/api/v1/" + strings.TrimPrefix(path, "/api/")
+		}
+		specRequest := httptest.NewRequest(method, path, bytes.NewReader(body))
+		specRequest.Header = tc.r.Header
+		route, params, err := router.FindRoute(specRequest)
+		require.NoError(t, err, tc.name)
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    specRequest,
+			PathParams: params,
+			Route:      route,
+			Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		}
+		if tc.method == "" {
+			require.NoError(t, openapi3filter.ValidateRequest(ctx, input), tc.name)
+		}
+		err = openapi3filter.ValidateResponse(ctx, &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 w.Code,
+			Header:                 w.Header(),
+			Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		})
+		require.NoError(t, err, tc.name)
+
+		if tc.code != "" {
+			res := &responseError{}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), res))
+			assert.Equal(t, tc.code, res.Code, tc.name)
+			assert.Equal(t, w.Header().Get("X-Request-Id"), res.RequestID, tc.name)
+		}
+	}
+}
+
+func idempotent(r *http.Request, key string) *http.Request {
+	r.Header.Set(headerIdempotencyKey, key)
+	return r
+}
+
+// fromIP makes the request come from another client (as all the requests
+// from the same ip are rate-limited together).
+func fromIP(r *http.Request, ip string) *http.Request {
+	r.RemoteAddr = ip + ":1234"
+	return r
+}
+
+func fundRequest(token, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/fund", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
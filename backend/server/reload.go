@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+const configReloadDelay = 250 * time.Millisecond
+
+var (
+	ErrConfigWatcherFailedToInitialise = errors.New("failed to initialise config file watcher")
+)
+
+// WatchConfig makes the server reload the config whenever the file changes or
+// the process receives SIGHUP.  Only the settings that can be changed at
+// runtime are applied (see config.Reload), the rest require a restart.
+func (s *Server) WatchConfig(path string, load func() (*config.Config, error)) {
+	s.configFile = filepath.Clean(path)
+	s.reloadConfig = load
+}
+
+// watchedFile is a file the server reloads when it changes.
+type watchedFile struct {
+	path   string
+	reload func(ctx context.Context)
+}
+
+// watchConfig reloads the config file, the denylist file and the tls
+// certificate (whichever are set) when they change, or when the process
+// receives SIGHUP.
+func (s *Server) watchConfig(ctx context.Context) error {
+	l := logutils.LoggerFromContext(ctx)
+
+	files := make([]watchedFile, 0, 4)
+	if s.reloadConfig != nil {
+		files = append(files, watchedFile{path: s.configFile, reload: s.reload})
+	}
+	if path := s.config().Denylist.File; path != "" {
+		files = append(files, watchedFile{path: filepath.Clean(path), reload: s.reloadDenylist})
+	}
+	if s.certificate != nil {
+		files = append(files,
+			watchedFile{path: filepath.Clean(s.certificate.certFile), reload: s.reloadCertificate},
+			watchedFile{path: filepath.Clean(s.certificate.keyFile), reload: s.reloadCertificate},
+		)
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrConfigWatcherFailedToInitialise, err)
+	}
+	// watch the directories, so that the files being replaced (as editors
+	// and deployment tools tend to do) are noticed too
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		if err := watcher.Add(filepath.Dir(f.path)); err != nil {
+			watcher.Close()
+			return fmt.Errorf("%w: %w", ErrConfigWatcherFailedToInitialise, err)
+		}
+		paths = append(paths, f.path)
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer watcher.Close()
+		defer signal.Stop(hangup)
+
+		// the files are often written in several steps, so the reload is
+		// delayed until they settle
+		var settled <-chan time.Time
+		changed := make(map[string]bool, len(files))
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case sig := <-hangup:
+				l.Info("Reload signal received", zap.String("signal", sig.String()))
+				for _, f := range files {
+					f.reload(ctx)
+				}
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				path := filepath.Clean(event.Name)
+				if !slices.Contains(paths, path) || event.Op == fsnotify.Chmod {
+					continue
+				}
+				changed[path] = true
+				settled = time.After(configReloadDelay)
+
+			case <-settled:
+				settled = nil
+				for _, f := range files {
+					if !changed[f.path] {
+						continue
+					}
+					delete(changed, f.path)
+					l.Info("File changed", zap.String("file", f.path))
+					f.reload(ctx)
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				l.Warn("File watcher failed", zap.Error(err))
+			}
+		}
+	}()
+
+	l.Info("Watching files for changes", zap.Strings("files", paths))
+	return nil
+}
+
+func (s *Server) reload(ctx context.Context) {
+	l := logutils.LoggerFromContext(ctx)
+
+	next, err := s.reloadConfig()
+	if err != nil {
+		l.Error("Failed to reload config", zap.Error(err))
+		return
+	}
+	if err := next.Validate(); err != nil {
+		l.Error("Failed to reload config", zap.Error(err))
+		return
+	}
+
+	s.mxConfig.Lock()
+	defer s.mxConfig.Unlock()
+
+	current := s.config()
+	reloaded := current.Reload(next)
+
+	if ignored := config.Diff(reloaded, next); len(ignored) > 0 {
+		l.Warn("Ignoring config changes that require restart",
+			zap.Strings("changes", changesToStrings(ignored)),
+		)
+	}
+
+	changes := config.Diff(current, reloaded)
+	if len(changes) == 0 {
+		l.Info("Config is unchanged")
+		return
+	}
+
+	s.cfg.Store(reloaded)
+	s.maintenance.Reload(reloaded)
+	if s.pow != nil {
+		s.pow.Reload(reloaded)
+	}
+	if s.reputation != nil {
+		s.reputation.Reload(reloaded)
+	}
+	if s.siwe != nil {
+		s.siwe.Reload(reloaded)
+	}
+
+	l.Info("Reloaded config",
+		zap.Strings("changes", changesToStrings(changes)),
+	)
+}
+
+func (s *Server) reloadDenylist(ctx context.Context) {
+	l := logutils.LoggerFromContext(ctx)
+
+	count, err := s.denylist.LoadFile()
+	if err != nil {
+		l.Error("Failed to reload denylist", zap.Error(err))
+		return
+	}
+
+	l.Info("Reloaded denylist", zap.Int("entries", count))
+}
+
+func changesToStrings(changes []config.Change) []string {
+	res := make([]string, 0, len(changes))
+	for _, c := range changes {
+		res = append(res, c.String())
+	}
+	return res
+}
@@ -2,72 +2,302 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/flashbots/eth-faucet/apikey"
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/captcha"
 	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/cors"
+	"github.com/flashbots/eth-faucet/denylist"
 	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/flashbots/eth-faucet/idempotency"
+	"github.com/flashbots/eth-faucet/ledger"
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/maintenance"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/pow"
 	"github.com/flashbots/eth-faucet/ratelimiter"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/flashbots/eth-faucet/reputation"
+	"github.com/flashbots/eth-faucet/siwe"
+	"github.com/flashbots/eth-faucet/tracing"
 	"github.com/flashbots/eth-faucet/txbuilder"
+	"github.com/flashbots/eth-faucet/webhook"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 var (
+	ErrAuditLogFailedToInitialise           = errors.New("failed to initialise audit log")
+	ErrCaptchaVerifierFailedToInitialise    = errors.New("failed to initialise captcha verifier")
+	ErrDenylistFailedToInitialise           = errors.New("failed to initialise denylist")
+	ErrIdempotencyFailedToInitialise        = errors.New("failed to initialise idempotency store")
+	ErrLedgerFailedToInitialise             = errors.New("failed to initialise ledger")
+	ErrMaintenanceFailedToInitialise        = errors.New("failed to initialise maintenance")
+	ErrPoWFailedToInitialise                = errors.New("failed to initialise proof-of-work")
 	ErrRatelimiterFailedToInitialise        = errors.New("failed to initialise rate-limiter")
+	ErrRedisFailedToConnect                 = errors.New("failed to connect to redis")
+	ErrReputationFailedToInitialise         = errors.New("failed to initialise reputation checker")
+	ErrSIWEVerifierFailedToInitialise       = errors.New("failed to initialise siwe verifier")
+	ErrTracingFailedToInitialise            = errors.New("failed to initialise tracing")
 	ErrTransactionBuilderFailedToInitialise = errors.New("failed to initialise transactions builder")
+	ErrWebhooksFailedToInitialise           = errors.New("failed to initialise webhooks")
 )
 
 type Server struct {
-	cfg         *config.Config
+	apikeys     *apikey.Store
+	auditLog    *zap.Logger
+	captcha     captcha.Verifier
+	cfg         atomic.Pointer[config.Config]
+	denylist    *denylist.Denylist
+	idempotency *idempotency.Store
+	ledger      ledger.Ledger
 	log         *zap.Logger
+	maintenance *maintenance.Maintenance
+	pow         *pow.PoW
 	ratelimiter *ratelimiter.RateLimiter
+	reputation  reputation.Checker
+	siwe        *siwe.Verifier
 	txbuilder   *txbuilder.TxBuilder
+	webhooks    *webhook.Dispatcher
+
+	certificate  *certificate
+	configFile   string
+	mxConfig     sync.Mutex     // serialises the config updates
+	outcomes     sync.WaitGroup // the payout outcomes being tracked
+	reloadConfig func() (*config.Config, error)
+	shutdown     chan struct{} // closed once the server shuts down
 }
 
 func New(cfg *config.Config) (*Server, error) {
-	ratelimiter, err := ratelimiter.New(cfg)
+	// all the subsystems share the same connection pool
+	_redis, err := redisutils.Connect(&cfg.Redis, &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRedisFailedToConnect, err)
+	}
+
+	ratelimiter, err := ratelimiter.New(cfg, _redis)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrRatelimiterFailedToInitialise, err)
 	}
 
+	apikeys := apikey.New(cfg, _redis)
+
+	captchaVerifier, err := captcha.New(&cfg.Captcha)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCaptchaVerifierFailedToInitialise, err)
+	}
+
+	var _pow *pow.PoW
+	if cfg.PoW.Enabled {
+		_pow, err = pow.New(cfg, _redis)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrPoWFailedToInitialise, err)
+		}
+	}
+
+	reputationChecker, err := reputation.New(cfg, _redis)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReputationFailedToInitialise, err)
+	}
+
+	var siweVerifier *siwe.Verifier
+	if cfg.SIWE.Enabled {
+		siweVerifier, err = siwe.New(cfg, _redis)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSIWEVerifierFailedToInitialise, err)
+		}
+	}
+
 	txbuilder, err := txbuilder.New(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrTransactionBuilderFailedToInitialise, err)
 	}
 
-	return &Server{
-		cfg:         cfg,
+	denylist, err := denylist.New(cfg, _redis)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDenylistFailedToInitialise, err)
+	}
+
+	var idempotencyStore *idempotency.Store
+	if cfg.Server.IdempotencyWindow > 0 {
+		idempotencyStore, err = idempotency.New(cfg, _redis)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrIdempotencyFailedToInitialise, err)
+		}
+	}
+
+	ledger, err := ledger.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrLedgerFailedToInitialise, err)
+	}
+
+	maintenance, err := maintenance.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrMaintenanceFailedToInitialise, err)
+	}
+
+	webhooks, err := webhook.New(cfg, _redis)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWebhooksFailedToInitialise, err)
+	}
+
+	auditLog := zap.L()
+	if cfg.Admin.AuditLogFile != "" {
+		fileLog, err := logutils.NewFileLogger(cfg.Admin.AuditLogFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrAuditLogFailedToInitialise, err)
+		}
+		auditLog = zap.New(zapcore.NewTee(auditLog.Core(), fileLog.Core()))
+	}
+
+	s := &Server{
+		apikeys:     apikeys,
+		auditLog:    auditLog,
+		captcha:     captchaVerifier,
+		denylist:    denylist,
+		idempotency: idempotencyStore,
+		ledger:      ledger,
 		log:         zap.L(),
+		maintenance: maintenance,
+		pow:         _pow,
 		ratelimiter: ratelimiter,
+		reputation:  reputationChecker,
+		siwe:        siweVerifier,
 		txbuilder:   txbuilder,
-	}, nil
+		webhooks:    webhooks,
+
+		shutdown: make(chan struct{}),
+	}
+	s.cfg.Store(cfg)
+
+	return s, nil
+}
+
+// api returns the mux of the api.  The routes are served under the versioned
+// prefix, and under the unversioned one as well (for the existing clients).
+func (s *Server) api() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handleNotFound)
+
+	route := func(path string, handler http.HandlerFunc) {
+		mux.HandleFunc("/api/v1"+path, handler)
+		mux.HandleFunc("/api"+path, handler)
+	}
+
+	route("/fund", s.handleFund)
+	route("/fund/{hash}/events", s.handleFundEvents)
+	route("/info", s.handleInfo)
+	route("/openapi.json", s.handleOpenAPI)
+	if s.pow != nil {
+		route("/challenge", s.handleChallenge)
+	}
+	if s.siwe != nil {
+		route("/siwe/nonce", s.handleSIWENonce)
+		route("/siwe/verify", s.handleSIWEVerify)
+	}
+
+	return mux
+}
+
+// config returns the current config.  The handlers should get it once, so
+// that a concurrent reload does not mix up the settings within a request.
+func (s *Server) config() *config.Config {
+	return s.cfg.Load()
 }
 
 func (s *Server) Run() error {
 	l := s.log
-	ctx := logutils.ContextWithLogger(context.Background(), l)
+	ctx, stopWatching := context.WithCancel(logutils.ContextWithLogger(context.Background(), l))
+	defer stopWatching()
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("/api/fund", s.handleFund)
-	mux.HandleFunc("/api/info", s.handleInfo)
-	handler := httplogger.Middleware(l, mux)
+	var tlsConfig *tls.Config
+	if s.config().Server.TLSEnabled() {
+		var err error
+		if tlsConfig, err = s.tlsConfig(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.watchConfig(ctx); err != nil {
+		return err
+	}
+
+	shutdownTracing, err := tracing.Setup(&s.config().Tracing)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTracingFailedToInitialise, err)
+	}
+
+	go s.txbuilder.TrackReceipts(ctx)
+
+	// stopped only once the server is down and the tracked outcomes are in,
+	// so that the deliveries queued by the last requests are accounted for
+	webhooksCtx, stopWebhooks := context.WithCancel(ctx)
+	defer stopWebhooks()
+	webhooksDone := make(chan struct{})
+	if s.webhooks != nil {
+		go func() {
+			defer close(webhooksDone)
+			s.webhooks.Run(webhooksCtx)
+		}()
+		go s.webhooks.WatchBalance(webhooksCtx, s.txbuilder)
+	} else {
+		close(webhooksDone)
+	}
+
+	corsConfig := func() *config.CORS {
+		return &s.config().CORS
+	}
+	handler := httplogger.Middleware(l, cors.Middleware(corsConfig, metrics.Middleware(s.api())))
+
+	// the probes stay out of the access log and the metrics
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", s.handleHealthz)
+	root.HandleFunc("GET /readyz", s.handleReadyz)
+	root.Handle("/", handler)
 
 	srv := &http.Server{
-		Addr:              s.cfg.Server.ListenAddress,
-		Handler:           handler,
+		Addr:              s.config().Server.ListenAddress,
+		Handler:           root,
 		MaxHeaderBytes:    1024,
 		ReadHeaderTimeout: 30 * time.Second,
 		ReadTimeout:       30 * time.Second,
+		TLSConfig:         tlsConfig,
 		WriteTimeout:      30 * time.Second,
 	}
+	// the event streams would otherwise hold the shutdown off
+	srv.RegisterOnShutdown(func() { close(s.shutdown) })
+
+	var adminSrv *http.Server
+	if s.config().Admin.Enabled() {
+		if adminSrv, err = s.adminServer(); err != nil {
+			return err
+		}
+		go s.runAdminServer(adminSrv)
+	}
 
+	var metricsSrv *http.Server
+	if s.config().Metrics.Enabled() {
+		metricsSrv = s.metricsServer()
+		go s.runMetricsServer(metricsSrv)
+	}
+
+	serverDown := make(chan struct{})
 	go func() {
+		defer close(serverDown)
+
 		terminator := make(chan os.Signal, 1)
 		signal.Notify(terminator, os.Interrupt, syscall.SIGTERM)
 		stop := <-terminator
@@ -76,6 +306,20 @@ func (s *Server) Run() error {
 
 		ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
+		if adminSrv != nil {
+			if err := adminSrv.Shutdown(ctx); err != nil {
+				l.Error("Admin server shutdown failed",
+					zap.Error(err),
+				)
+			}
+		}
+		if metricsSrv != nil {
+			if err := metricsSrv.Shutdown(ctx); err != nil {
+				l.Error("Metrics server shutdown failed",
+					zap.Error(err),
+				)
+			}
+		}
 		if err := srv.Shutdown(ctx); err != nil {
 			l.Error("HTTP server shutdown failed",
 				zap.Error(err),
@@ -84,11 +328,36 @@ func (s *Server) Run() error {
 	}()
 
 	l.Info("Starting up faucet server...",
-		zap.String("server_listen_address", s.cfg.Server.ListenAddress),
+		zap.String("server_listen_address", s.config().Server.ListenAddress),
+		zap.Bool("tls", tlsConfig != nil),
 	)
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if tlsConfig != nil {
+		// the certificate comes from the tls config (http/2 is negotiated
+		// automatically)
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		// the server returns as soon as the shutdown starts, the requests
+		// in flight are done only once it completes
+		<-serverDown
+	} else if err != nil {
 		l.Error("Faucet server failed", zap.Error(err))
 	}
+	s.outcomes.Wait()
+	stopWebhooks()
+	<-webhooksDone
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		l.Error("Failed to flush the traces", zap.Error(err))
+	}
+	if s.ledger != nil {
+		if err := s.ledger.Close(); err != nil {
+			l.Error("Failed to close the ledger", zap.Error(err))
+		}
+	}
 	l.Info("Server is down")
 
 	return nil
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrClientCAInvalid            = errors.New("client ca file contains no certificates")
+	ErrTLSFailedToInitialise      = errors.New("failed to initialise tls")
+	ErrTLSCertificateFailedToLoad = errors.New("failed to load tls certificate")
+)
+
+// certificate is the server's tls certificate, which is re-read from the
+// files when they change (e.g. when they are renewed).
+type certificate struct {
+	certFile string
+	keyFile  string
+
+	current atomic.Pointer[tls.Certificate]
+}
+
+func newCertificate(certFile, keyFile string) (*certificate, error) {
+	c := &certificate{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certificate) load() error {
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrTLSCertificateFailedToLoad, err)
+	}
+	c.current.Store(&cert)
+	return nil
+}
+
+func (c *certificate) get(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return c.current.Load(), nil
+}
+
+// tlsConfig returns the tls config of the faucet server.  With the client ca
+// the clients may present a certificate (which then identifies them instead
+// of a jwt).
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	cfg := s.config().Server
+
+	cert, err := newCertificate(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTLSFailedToInitialise, err)
+	}
+	s.certificate = cert
+
+	tlsConfig := &tls.Config{
+		GetCertificate: cert.get,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if cfg.ClientCA != "" {
+		pem, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrTLSFailedToInitialise, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: %w: %s", ErrTLSFailedToInitialise, ErrClientCAInvalid, cfg.ClientCA)
+		}
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		tlsConfig.ClientCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func (s *Server) reloadCertificate(ctx context.Context) {
+	l := logutils.LoggerFromContext(ctx)
+
+	if err := s.certificate.load(); err != nil {
+		// keep serving the previous one
+		l.Error("Failed to reload tls certificate", zap.Error(err))
+		return
+	}
+
+	l.Info("Reloaded tls certificate")
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/flashbots/eth-faucet/httplogger"
+	"github.com/flashbots/eth-faucet/ledger"
+	"github.com/flashbots/eth-faucet/units"
+	"github.com/flashbots/eth-faucet/webhook"
+)
+
+// notifyFund sends the fund.* event about the attempt to the webhooks.
+func (s *Server) notifyFund(r *http.Request, event string, attempt *ledger.Entry) {
+	if s.webhooks == nil {
+		return
+	}
+	s.webhooks.Send(r.Context(), event, s.webhookFund(r, attempt))
+}
+
+func (s *Server) webhookFund(r *http.Request, attempt *ledger.Entry) *webhook.Fund {
+	data := &webhook.Fund{
+		Address:   attempt.Address,
+		APIKeyID:  attempt.APIKeyID,
+		Error:     attempt.Error,
+		Provider:  attempt.Provider,
+		RequestID: httplogger.RequestID(r),
+		TxHash:    attempt.TxHash,
+		Username:  attempt.Username,
+	}
+	if attempt.Amount != nil {
+		data.Amount = units.Format(attempt.Amount, s.config().Chain.TokenDecimals)
+		data.AmountWei = attempt.Amount.String()
+	}
+	return data
+}
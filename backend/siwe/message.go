@@ -0,0 +1,157 @@
+package siwe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	headerSuffix = " wants you to sign in with your Ethereum account:"
+
+	fieldChainID        = "Chain ID: "
+	fieldExpirationTime = "Expiration Time: "
+	fieldIssuedAt       = "Issued At: "
+	fieldNonce          = "Nonce: "
+	fieldNotBefore      = "Not Before: "
+	fieldRequestID      = "Request ID: "
+	fieldResources      = "Resources:"
+	fieldURI            = "URI: "
+	fieldVersion        = "Version: "
+)
+
+var (
+	ErrMessageInvalidAddress = errors.New("siwe message has invalid address")
+	ErrMessageInvalidField   = errors.New("siwe message has invalid field")
+	ErrMessageInvalidHeader  = errors.New("siwe message has invalid header")
+	ErrMessageMissingField   = errors.New("siwe message is missing mandatory field")
+	ErrMessageTooShort       = errors.New("siwe message is too short")
+)
+
+// Message is a parsed EIP-4361 (Sign-In with Ethereum) message.
+type Message struct {
+	Domain         string
+	Address        common.Address
+	Statement      string
+	URI            string
+	Version        string
+	ChainID        uint64
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime *time.Time
+	NotBefore      *time.Time
+	RequestID      string
+	Resources      []string
+}
+
+func ParseMessage(message string) (*Message, error) {
+	lines := strings.Split(message, "\n")
+	if len(lines) < 4 {
+		return nil, ErrMessageTooShort
+	}
+
+	m := &Message{}
+
+	header := lines[0]
+	if !strings.HasSuffix(header, headerSuffix) {
+		return nil, ErrMessageInvalidHeader
+	}
+	m.Domain = strings.TrimSuffix(header, headerSuffix)
+	if _, domain, found := strings.Cut(m.Domain, "://"); found {
+		m.Domain = domain
+	}
+	if m.Domain == "" {
+		return nil, ErrMessageInvalidHeader
+	}
+
+	if !common.IsHexAddress(lines[1]) || !strings.HasPrefix(lines[1], "0x") {
+		return nil, fmt.Errorf("%w: %s", ErrMessageInvalidAddress, lines[1])
+	}
+	m.Address = common.HexToAddress(lines[1])
+
+	// address is followed by an empty line, optional statement with its own
+	// trailing empty line, and then by the fields
+	idx := 3
+	switch {
+	case lines[2] != "":
+		return nil, fmt.Errorf("%w: expected empty line after address", ErrMessageInvalidField)
+	case strings.HasPrefix(lines[3], fieldURI):
+		// no statement (legacy formatting without the extra empty line)
+	case lines[3] == "":
+		idx = 4
+	default:
+		if len(lines) < 5 || lines[4] != "" {
+			return nil, fmt.Errorf("%w: expected empty line after statement", ErrMessageInvalidField)
+		}
+		m.Statement = lines[3]
+		idx = 5
+	}
+
+	var err error
+	seen := make(map[string]bool)
+	for ; idx < len(lines); idx++ {
+		line := lines[idx]
+		switch {
+		case strings.HasPrefix(line, fieldURI):
+			m.URI = strings.TrimPrefix(line, fieldURI)
+		case strings.HasPrefix(line, fieldVersion):
+			m.Version = strings.TrimPrefix(line, fieldVersion)
+		case strings.HasPrefix(line, fieldChainID):
+			m.ChainID, err = strconv.ParseUint(strings.TrimPrefix(line, fieldChainID), 10, 64)
+		case strings.HasPrefix(line, fieldNonce):
+			m.Nonce = strings.TrimPrefix(line, fieldNonce)
+		case strings.HasPrefix(line, fieldIssuedAt):
+			m.IssuedAt, err = time.Parse(time.RFC3339, strings.TrimPrefix(line, fieldIssuedAt))
+		case strings.HasPrefix(line, fieldExpirationTime):
+			m.ExpirationTime, err = parseTime(strings.TrimPrefix(line, fieldExpirationTime))
+		case strings.HasPrefix(line, fieldNotBefore):
+			m.NotBefore, err = parseTime(strings.TrimPrefix(line, fieldNotBefore))
+		case strings.HasPrefix(line, fieldRequestID):
+			m.RequestID = strings.TrimPrefix(line, fieldRequestID)
+		case line == fieldResources:
+			for idx+1 < len(lines) && strings.HasPrefix(lines[idx+1], "- ") {
+				idx++
+				m.Resources = append(m.Resources, strings.TrimPrefix(lines[idx], "- "))
+			}
+		case line == "" && idx == len(lines)-1:
+			// trailing new line
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrMessageInvalidField, line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrMessageInvalidField, line, err)
+		}
+		key, _, _ := strings.Cut(line, ":")
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate %s", ErrMessageInvalidField, key)
+		}
+		seen[key] = true
+	}
+
+	switch {
+	case m.URI == "":
+		return nil, fmt.Errorf("%w: %s", ErrMessageMissingField, strings.TrimSpace(fieldURI))
+	case m.Version == "":
+		return nil, fmt.Errorf("%w: %s", ErrMessageMissingField, strings.TrimSpace(fieldVersion))
+	case m.ChainID == 0:
+		return nil, fmt.Errorf("%w: %s", ErrMessageMissingField, strings.TrimSpace(fieldChainID))
+	case m.Nonce == "":
+		return nil, fmt.Errorf("%w: %s", ErrMessageMissingField, strings.TrimSpace(fieldNonce))
+	case m.IssuedAt.IsZero():
+		return nil, fmt.Errorf("%w: %s", ErrMessageMissingField, strings.TrimSpace(fieldIssuedAt))
+	}
+
+	return m, nil
+}
+
+func parseTime(value string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
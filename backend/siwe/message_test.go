@@ -0,0 +1,62 @@
+package siwe_test
+
+import (
+	"testing"
+
+	"github.com/flashbots/eth-faucet/siwe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMessage(t *testing.T) {
+	message := "faucet.example.com wants you to sign in with your Ethereum account:\n" +
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n" +
+		"\n" +
+		"Sign in to the faucet.\n" +
+		"\n" +
+		"URI: https://faucet.example.com\n" +
+		"Version: 1\n" +
+		"Chain ID: 1\n" +
+		"Nonce: 32891756abcdefgh\n" +
+		"Issued At: 2024-03-01T16:25:24Z\n" +
+		"Expiration Time: 2024-03-01T16:30:24Z\n" +
+		"Resources:\n" +
+		"- https://faucet.example.com/terms"
+
+	m, err := siwe.ParseMessage(message)
+	require.NoError(t, err)
+	assert.Equal(t, "faucet.example.com", m.Domain)
+	assert.Equal(t, "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2", m.Address.Hex())
+	assert.Equal(t, "Sign in to the faucet.", m.Statement)
+	assert.Equal(t, uint64(1), m.ChainID)
+	assert.Equal(t, "32891756abcdefgh", m.Nonce)
+	assert.NotNil(t, m.ExpirationTime)
+	assert.Nil(t, m.NotBefore)
+	assert.Equal(t, []string{"https://faucet.example.com/terms"}, m.Resources)
+
+	withoutStatement := "faucet.example.com wants you to sign in with your Ethereum account:\n" +
+		"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n" +
+		"\n" +
+		"\n" +
+		"URI: https://faucet.example.com\n" +
+		"Version: 1\n" +
+		"Chain ID: 1\n" +
+		"Nonce: 32891756abcdefgh\n" +
+		"Issued At: 2024-03-01T16:25:24Z"
+
+	m, err = siwe.ParseMessage(withoutStatement)
+	require.NoError(t, err)
+	assert.Empty(t, m.Statement)
+
+	_, err = siwe.ParseMessage(
+		"faucet.example.com wants you to sign in with your Ethereum account:\n" +
+			"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2\n" +
+			"\n" +
+			"\n" +
+			"URI: https://faucet.example.com\n" +
+			"Version: 1\n" +
+			"Chain ID: 1\n" +
+			"Issued At: 2024-03-01T16:25:24Z",
+	)
+	assert.ErrorIs(t, err, siwe.ErrMessageMissingField)
+}
@@ -0,0 +1,304 @@
+package siwe
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/flashbots/eth-faucet/rpcutils"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+const (
+	nonceAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	nonceLength   = 17
+)
+
+var (
+	ErrFailedToCheckMainnetHistory = errors.New("failed to check signer's mainnet history")
+	ErrFailedToConnectToRPC        = errors.New("failed to connect to rpc")
+	ErrFailedToGetChainID          = errors.New("failed to get chain id from rpc")
+	ErrFailedToVerifyContractSig   = errors.New("failed to verify contract wallet signature")
+	ErrMessageChainIDMismatch      = errors.New("siwe message chain id does not match")
+	ErrMessageDomainMismatch       = errors.New("siwe message domain does not match")
+	ErrMessageExpired              = errors.New("siwe message is expired")
+	ErrMessageNonceUnknown         = errors.New("siwe message nonce is unknown or already used")
+	ErrMessageNotYetValid          = errors.New("siwe message is not yet valid")
+	ErrMessageURIMismatch          = errors.New("siwe message uri does not match the domain")
+	ErrMessageVersionUnsupported   = errors.New("siwe message version is not supported")
+	ErrSignatureInvalid            = errors.New("siwe signature is invalid")
+	ErrSignerHasNoMainnetHistory   = errors.New("siwe signer does not have enough mainnet history")
+)
+
+// eip1271MagicValue is both the selector of `isValidSignature(bytes32,bytes)`
+// and the value contract wallets return from it when the signature is valid.
+var eip1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+type Verifier struct {
+	backoffParams    *backoff.Parameters
+	cfg              atomic.Pointer[config.SIWE]
+	chainID          atomic.Uint64
+	prefix           string
+	redis            *redis.Client
+	rpcBackoffParams *backoff.Parameters
+
+	chainClient   ethereum.ContractCaller
+	mainnetClient *ethclient.Client
+}
+
+func New(cfg *config.Config, _redis *redis.Client) (*Verifier, error) {
+	backoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+	rpcBackoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.RPC.Timeout,
+	}
+
+	v := &Verifier{
+		backoffParams:    backoffParams,
+		prefix:           redisutils.Prefix(&cfg.Redis),
+		redis:            _redis,
+		rpcBackoffParams: rpcBackoffParams,
+	}
+	v.Reload(cfg)
+
+	// the messages must be issued for the faucet's chain, which is asked
+	// from the rpc unless configured
+	if cfg.SIWE.EIP1271 || cfg.Chain.ID == 0 {
+		client, err := rpcutils.Connect(&cfg.RPC, rpcBackoffParams)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToConnectToRPC, err)
+		}
+		if cfg.Chain.ID == 0 {
+			err := backoff.Backoff(context.Background(), rpcBackoffParams, func(ctx context.Context) error {
+				chainID, err := client.ChainID(ctx)
+				if err != nil {
+					return err
+				}
+				v.chainID.Store(chainID.Uint64())
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrFailedToGetChainID, err)
+			}
+		}
+		if cfg.SIWE.EIP1271 {
+			v.chainClient = client
+		} else {
+			client.Close()
+		}
+	}
+	if cfg.SIWE.MainnetRPCEndpoint != "" {
+		var err error
+		if v.mainnetClient, err = dial(cfg.SIWE.MainnetRPCEndpoint, rpcBackoffParams); err != nil {
+			return nil, err
+		}
+	}
+
+	return v, nil
+}
+
+// Reload applies the changed settings.  The connections (and so the eip-1271
+// and the mainnet checks) stay as they were made on start.  The chain id of
+// zero keeps the one in use (asked from the rpc, or configured before).
+func (v *Verifier) Reload(cfg *config.Config) {
+	siwe := cfg.SIWE
+	v.cfg.Store(&siwe)
+	if cfg.Chain.ID != 0 {
+		v.chainID.Store(cfg.Chain.ID)
+	}
+}
+
+// Nonce issues a single-use nonce that the client must embed into the siwe
+// message.
+func (v *Verifier) Nonce(ctx context.Context) (string, error) {
+	b := make([]byte, nonceLength)
+	alphabetSize := big.NewInt(int64(len(nonceAlphabet)))
+	for i := range b {
+		// uniform, unlike a random byte modulo the size of the alphabet
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		b[i] = nonceAlphabet[n.Int64()]
+	}
+	nonce := string(b)
+
+	err := backoff.Backoff(ctx, v.backoffParams, func(ctx context.Context) error {
+		return v.redis.Set(ctx, v.nonceKey(nonce), time.Now().Format(time.RFC3339), v.cfg.Load().NonceTTL).Err()
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return nonce, nil
+}
+
+// Verify parses the siwe message, checks its validity and signature, and
+// consumes its nonce.  It returns the parsed message on success.
+//
+// The nonce is consumed only once the signature is verified, so that the
+// forged messages can not burn the nonces issued to others.
+func (v *Verifier) Verify(ctx context.Context, message string, signature []byte) (*Message, error) {
+	m, err := ParseMessage(message)
+	if err != nil {
+		return nil, err
+	}
+	cfg := v.cfg.Load()
+
+	if m.Version != "1" {
+		return nil, fmt.Errorf("%w: %s", ErrMessageVersionUnsupported, m.Version)
+	}
+	if m.Domain != cfg.Domain {
+		return nil, fmt.Errorf("%w: %s", ErrMessageDomainMismatch, m.Domain)
+	}
+	if uri, err := url.Parse(m.URI); err != nil || uri.Host != cfg.Domain {
+		return nil, fmt.Errorf("%w: %s", ErrMessageURIMismatch, m.URI)
+	}
+	if m.ChainID != v.chainID.Load() {
+		return nil, fmt.Errorf("%w: %d", ErrMessageChainIDMismatch, m.ChainID)
+	}
+	now := time.Now()
+	if m.ExpirationTime != nil && now.After(*m.ExpirationTime) {
+		return nil, ErrMessageExpired
+	}
+	if m.NotBefore != nil && now.Before(*m.NotBefore) {
+		return nil, ErrMessageNotYetValid
+	}
+
+	hash := accounts.TextHash([]byte(message))
+	if err := v.verifySignature(ctx, m.Address, hash, signature); err != nil {
+		return nil, err
+	}
+
+	if err := v.consumeNonce(ctx, m.Nonce); err != nil {
+		return nil, err
+	}
+
+	if v.mainnetClient != nil && cfg.MainnetMinTxCount > 0 {
+		var txCount uint64
+		err := backoff.Backoff(ctx, v.rpcBackoffParams, func(ctx context.Context) (_err error) {
+			txCount, _err = v.mainnetClient.NonceAt(ctx, m.Address, nil)
+			return
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrFailedToCheckMainnetHistory, err)
+		}
+		if txCount < cfg.MainnetMinTxCount {
+			return nil, fmt.Errorf("%w: %d < %d", ErrSignerHasNoMainnetHistory, txCount, cfg.MainnetMinTxCount)
+		}
+	}
+
+	return m, nil
+}
+
+// consumeNonce deletes the nonce, which only one of the concurrent requests
+// can do.
+func (v *Verifier) consumeNonce(ctx context.Context, nonce string) error {
+	var deleted int64
+	err := backoff.Backoff(ctx, v.backoffParams, func(ctx context.Context) (_err error) {
+		deleted, _err = v.redis.Del(ctx, v.nonceKey(nonce)).Result()
+		return
+	})
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrMessageNonceUnknown
+	}
+	return nil
+}
+
+func (v *Verifier) verifySignature(ctx context.Context, address common.Address, hash []byte, signature []byte) error {
+	if len(signature) == crypto.SignatureLength {
+		sig := bytes.Clone(signature)
+		if sig[crypto.RecoveryIDOffset] >= 27 {
+			sig[crypto.RecoveryIDOffset] -= 27
+		}
+		if pub, err := crypto.SigToPub(hash, sig); err == nil && crypto.PubkeyToAddress(*pub) == address {
+			return nil
+		}
+	}
+
+	if v.chainClient == nil {
+		return ErrSignatureInvalid
+	}
+
+	// EIP-1271: ask the contract wallet whether the signature is valid
+	calldata, err := eip1271Calldata(hash, signature)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToVerifyContractSig, err)
+	}
+	var res []byte
+	err = backoff.Backoff(ctx, v.rpcBackoffParams, func(ctx context.Context) (_err error) {
+		res, _err = v.chainClient.CallContract(ctx, ethereum.CallMsg{
+			To:   &address,
+			Data: calldata,
+		}, nil)
+		return
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrFailedToVerifyContractSig, err)
+	}
+	if len(res) < len(eip1271MagicValue) || !bytes.Equal(res[:len(eip1271MagicValue)], eip1271MagicValue) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+func (v *Verifier) nonceKey(nonce string) string {
+	return v.prefix + "siwe-nonce:" + nonce
+}
+
+func eip1271Calldata(hash []byte, signature []byte) ([]byte, error) {
+	bytes32, err := abi.NewType("bytes32", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	_bytes, err := abi.NewType("bytes", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	args, err := abi.Arguments{{Type: bytes32}, {Type: _bytes}}.Pack(
+		common.BytesToHash(hash), signature,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return append(bytes.Clone(eip1271MagicValue), args...), nil
+}
+
+func dial(endpoint string, backoffParams *backoff.Parameters) (*ethclient.Client, error) {
+	l := zap.L()
+
+	l.Info("Connecting to rpc endpoint...", zap.String("rpc_endpoint", endpoint))
+	var client *ethclient.Client
+	err := backoff.Backoff(context.Background(), backoffParams, func(_ context.Context) (_err error) {
+		client, _err = ethclient.Dial(endpoint)
+		if _err != nil {
+			l.Warn("Failed to connect to rpc endpoint", zap.Error(_err))
+		}
+		return _err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrFailedToConnectToRPC, err)
+	}
+
+	return client, nil
+}
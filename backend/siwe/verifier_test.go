@@ -0,0 +1,206 @@
+package siwe
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type contractCallerStub struct {
+	calls int
+	valid bool
+}
+
+func (c *contractCallerStub) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	c.calls++
+	if !c.valid {
+		return make([]byte, 32), nil
+	}
+	return common.RightPadBytes(eip1271MagicValue, 32), nil
+}
+
+func newVerifier(t *testing.T) *Verifier {
+	v, err := New(&config.Config{
+		Chain: config.Chain{ID: 1337},
+		Redis: config.Redis{Timeout: time.Second},
+		RPC:   config.RPC{Timeout: time.Second},
+		SIWE: config.SIWE{
+			Domain:   "faucet.example.com",
+			Enabled:  true,
+			NonceTTL: time.Minute,
+		},
+	}, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+	return v
+}
+
+func message(address common.Address, nonce string, edit func(fields map[string]string)) string {
+	fields := map[string]string{
+		"domain":     "faucet.example.com",
+		"uri":        "https://faucet.example.com",
+		"chain_id":   "1337",
+		"expiration": "",
+	}
+	if edit != nil {
+		edit(fields)
+	}
+	m := fields["domain"] + headerSuffix + "\n" +
+		address.Hex() + "\n" +
+		"\n" +
+		fieldURI + fields["uri"] + "\n" +
+		fieldVersion + "1\n" +
+		fieldChainID + fields["chain_id"] + "\n" +
+		fieldNonce + nonce + "\n" +
+		fieldIssuedAt + time.Now().UTC().Format(time.RFC3339)
+	if fields["expiration"] != "" {
+		m += "\n" + fieldExpirationTime + fields["expiration"]
+	}
+	return m
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, message string) []byte {
+	signature, err := crypto.Sign(accounts.TextHash([]byte(message)), key)
+	require.NoError(t, err)
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	v := newVerifier(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	nonce, err := v.Nonce(ctx)
+	require.NoError(t, err)
+	msg := message(address, nonce, nil)
+
+	// the forged signature does not burn the nonce
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = v.Verify(ctx, msg, sign(t, other, msg))
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+
+	m, err := v.Verify(ctx, msg, sign(t, key, msg))
+	require.NoError(t, err)
+	assert.Equal(t, address, m.Address)
+
+	// the nonce is single-use
+	_, err = v.Verify(ctx, msg, sign(t, key, msg))
+	assert.ErrorIs(t, err, ErrMessageNonceUnknown)
+
+	msg = message(address, "unknown1234567890", nil)
+	_, err = v.Verify(ctx, msg, sign(t, key, msg))
+	assert.ErrorIs(t, err, ErrMessageNonceUnknown)
+}
+
+func TestVerifyFields(t *testing.T) {
+	ctx := context.Background()
+	v := newVerifier(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	for expected, edit := range map[error]func(map[string]string){
+		ErrMessageChainIDMismatch: func(f map[string]string) { f["chain_id"] = "1" },
+		ErrMessageDomainMismatch:  func(f map[string]string) { f["domain"] = "evil.example.com" },
+		ErrMessageExpired:         func(f map[string]string) { f["expiration"] = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339) },
+		ErrMessageURIMismatch:     func(f map[string]string) { f["uri"] = "https://evil.example.com/faucet.example.com" },
+	} {
+		nonce, err := v.Nonce(ctx)
+		require.NoError(t, err)
+		msg := message(address, nonce, edit)
+
+		_, err = v.Verify(ctx, msg, sign(t, key, msg))
+		assert.ErrorIs(t, err, expected)
+
+		// the nonce is left for the valid message
+		msg = message(address, nonce, nil)
+		_, err = v.Verify(ctx, msg, sign(t, key, msg))
+		assert.NoError(t, err, "after %v", expected)
+	}
+}
+
+func TestVerifyReload(t *testing.T) {
+	ctx := context.Background()
+	v := newVerifier(t)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	v.Reload(&config.Config{
+		Chain: config.Chain{ID: 1338},
+		SIWE:  config.SIWE{Domain: "faucet.example.org", Enabled: true, NonceTTL: time.Minute},
+	})
+
+	nonce, err := v.Nonce(ctx)
+	require.NoError(t, err)
+	msg := message(address, nonce, nil)
+	_, err = v.Verify(ctx, msg, sign(t, key, msg))
+	assert.ErrorIs(t, err, ErrMessageDomainMismatch)
+
+	msg = message(address, nonce, func(f map[string]string) {
+		f["domain"], f["uri"] = "faucet.example.org", "https://faucet.example.org"
+	})
+	_, err = v.Verify(ctx, msg, sign(t, key, msg))
+	assert.ErrorIs(t, err, ErrMessageChainIDMismatch)
+
+	msg = message(address, nonce, func(f map[string]string) {
+		f["domain"], f["uri"], f["chain_id"] = "faucet.example.org", "https://faucet.example.org", "1338"
+	})
+	_, err = v.Verify(ctx, msg, sign(t, key, msg))
+	assert.NoError(t, err)
+}
+
+func TestNonce(t *testing.T) {
+	v := newVerifier(t)
+
+	nonce, err := v.Nonce(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, nonce, nonceLength)
+	assert.Empty(t, strings.Trim(nonce, nonceAlphabet))
+}
+
+func TestVerifyEIP1271(t *testing.T) {
+	ctx := context.Background()
+	v := newVerifier(t)
+
+	wallet := common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	signature := []byte("signed by the owners")
+
+	nonce, err := v.Nonce(ctx)
+	require.NoError(t, err)
+	msg := message(wallet, nonce, nil)
+
+	// not verified via rpc unless enabled
+	_, err = v.Verify(ctx, msg, signature)
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+
+	stub := &contractCallerStub{}
+	v.chainClient = stub
+	_, err = v.Verify(ctx, msg, signature)
+	assert.ErrorIs(t, err, ErrSignatureInvalid)
+	assert.Equal(t, 1, stub.calls)
+
+	stub.valid = true
+	m, err := v.Verify(ctx, msg, signature)
+	require.NoError(t, err)
+	assert.Equal(t, wallet, m.Address)
+}
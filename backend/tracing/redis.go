@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RedisHook traces the redis commands (without their arguments, which are
+// the rate-limited identities and addresses).
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := Start(ctx, "redis "+cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				semconv.DBOperationName(cmd.Name()),
+			),
+		)
+		err := next(ctx, cmd)
+		if errors.Is(err, redis.Nil) {
+			// a missing key is a valid answer
+			End(span, nil)
+		} else {
+			End(span, err)
+		}
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := Start(ctx, "redis pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemRedis,
+				attribute.Int("db.redis.pipeline_length", len(cmds)),
+			),
+		)
+		err := next(ctx, cmds)
+		End(span, err)
+		return err
+	}
+}
@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/flashbots/eth-faucet/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/flashbots/eth-faucet"
+
+var (
+	ErrExporterFailedToInitialise = errors.New("failed to initialise trace exporter")
+)
+
+// Setup installs the tracer provider that exports the spans via otlp/http,
+// and returns the function that flushes the remaining spans on shutdown.
+// Unless configured, the spans are not recorded at all.
+func Setup(cfg *config.Tracing) (func(context.Context) error, error) {
+	if !cfg.Enabled() {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExporterFailedToInitialise, err)
+	}
+	if strings.Trim(endpoint.Path, "/") == "" {
+		// the collector's default
+		endpoint = endpoint.JoinPath("v1", "traces")
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(endpoint.String()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExporterFailedToInitialise, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(
+			semconv.ServiceName(cfg.ServiceName),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(
+			sdktrace.TraceIDRatioBased(cfg.SampleRatio),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Start starts the span with the globally installed tracer provider.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End ends the span, marking it as failed if there was an error.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,42 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	// a stand-in for the collector
+	received := make(chan *http.Request, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer collector.Close()
+
+	shutdown, err := tracing.Setup(&config.Tracing{
+		Endpoint:    collector.URL,
+		SampleRatio: 1,
+		ServiceName: "eth-faucet",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	_, span := tracing.Start(context.Background(), "test")
+	assert.True(t, span.IsRecording())
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+	r := <-received
+	assert.Equal(t, "/v1/traces", r.URL.Path)
+	assert.Equal(t, "application/x-protobuf", r.Header.Get("content-type"))
+}
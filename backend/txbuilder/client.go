@@ -0,0 +1,102 @@
+package txbuilder
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedClient traces the rpc requests the transactions builder makes,
+// and records their latency.
+type instrumentedClient struct {
+	*ethclient.Client
+}
+
+func (c instrumentedClient) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	ctx, done := instrument(ctx, "eth_getBalance")
+	balance, err := c.Client.BalanceAt(ctx, account, blockNumber)
+	done(err)
+	return balance, err
+}
+
+func (c instrumentedClient) ChainID(ctx context.Context) (*big.Int, error) {
+	ctx, done := instrument(ctx, "eth_chainId")
+	chainID, err := c.Client.ChainID(ctx)
+	done(err)
+	return chainID, err
+}
+
+func (c instrumentedClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	ctx, done := instrument(ctx, "eth_getBlockByNumber")
+	header, err := c.Client.HeaderByNumber(ctx, number)
+	done(err)
+	return header, err
+}
+
+func (c instrumentedClient) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	ctx, done := instrument(ctx, "eth_getTransactionCount")
+	nonce, err := c.Client.NonceAt(ctx, account, blockNumber)
+	done(err)
+	return nonce, err
+}
+
+func (c instrumentedClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	ctx, done := instrument(ctx, "eth_getTransactionCount")
+	nonce, err := c.Client.PendingNonceAt(ctx, account)
+	done(err)
+	return nonce, err
+}
+
+func (c instrumentedClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	ctx, done := instrument(ctx, "eth_sendRawTransaction")
+	err := c.Client.SendTransaction(ctx, tx)
+	done(err)
+	return err
+}
+
+func (c instrumentedClient) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	ctx, done := instrument(ctx, "eth_gasPrice")
+	price, err := c.Client.SuggestGasPrice(ctx)
+	done(err)
+	return price, err
+}
+
+func (c instrumentedClient) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	ctx, done := instrument(ctx, "eth_getTransactionByHash")
+	tx, pending, err := c.Client.TransactionByHash(ctx, hash)
+	done(err)
+	return tx, pending, err
+}
+
+func (c instrumentedClient) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	ctx, done := instrument(ctx, "eth_getTransactionReceipt")
+	receipt, err := c.Client.TransactionReceipt(ctx, hash)
+	done(err)
+	return receipt, err
+}
+
+// instrument starts the span of the rpc request, and returns the function
+// that ends it once the response is there.
+func instrument(ctx context.Context, method string) (context.Context, func(error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			semconv.RPCMethod(method),
+		),
+	)
+	return ctx, func(err error) {
+		metrics.ObserveRPC(method, start)
+		tracing.End(span, err)
+	}
+}
@@ -0,0 +1,261 @@
+package txbuilder
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/flashbots/eth-faucet/logutils"
+	"go.uber.org/zap"
+)
+
+// The states of the transactions sent by the faucet.
+const (
+	StateBroadcast = "broadcast" // sent, but not yet included
+	StateIncluded  = "included"  // included, but not yet confirmed
+	StateConfirmed = "confirmed" // included and confirmed by enough blocks
+	StateFailed    = "failed"    // included, but reverted
+	StateReplaced  = "replaced"  // its nonce was taken by another transaction
+)
+
+const (
+	// receiptsPollInterval is how often the receipts of the tracked
+	// transactions are checked
+	receiptsPollInterval = 2 * time.Second
+
+	// receiptsRetention is for how long the final status is remembered (for
+	// the late subscribers)
+	receiptsRetention = time.Hour
+)
+
+var (
+	ErrTransactionUnknown = errors.New("transaction is unknown")
+)
+
+// Status is the state of a transaction sent by the faucet.
+type Status struct {
+	State         string      `json:"state"`
+	Hash          common.Hash `json:"hash"`
+	BlockNumber   uint64      `json:"block_number,omitempty"`
+	Confirmations uint64      `json:"confirmations,omitempty"`
+}
+
+// Final tells whether the status is not going to change anymore.
+func (s Status) Final() bool {
+	return s.State == StateConfirmed || s.State == StateFailed || s.State == StateReplaced
+}
+
+type trackedTransaction struct {
+	nonce       uint64
+	status      Status
+	updatedAt   time.Time
+	subscribers map[chan Status]struct{}
+}
+
+// Subscribe returns the channel that receives the current status of the
+// transaction, and then its every change.  The channel is closed after the
+// final status (or once unsubscribed).
+//
+// The transactions sent by other replicas of the faucet (or before the
+// restart) are looked up on the rpc.
+func (tb *TxBuilder) Subscribe(ctx context.Context, hash common.Hash) (<-chan Status, func(), error) {
+	tb.mxTracked.Lock()
+	_, known := tb.tracked[hash]
+	tb.mxTracked.Unlock()
+
+	if !known {
+		tx, _, err := tb.client.TransactionByHash(ctx, hash)
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, nil, ErrTransactionUnknown
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if from, err := types.Sender(tb.signer, tx); err != nil || from != tb.address {
+			return nil, nil, ErrTransactionUnknown
+		}
+		tb.track(hash, tx.Nonce())
+	}
+
+	tb.mxTracked.Lock()
+	defer tb.mxTracked.Unlock()
+
+	tracked, ok := tb.tracked[hash]
+	if !ok {
+		// forgotten in the meantime
+		return nil, nil, ErrTransactionUnknown
+	}
+
+	ch := make(chan Status, 8)
+	ch <- tracked.status
+	if tracked.status.Final() {
+		close(ch)
+		return ch, func() {}, nil
+	}
+
+	tracked.subscribers[ch] = struct{}{}
+	unsubscribe := func() {
+		tb.mxTracked.Lock()
+		defer tb.mxTracked.Unlock()
+		if _, ok := tracked.subscribers[ch]; ok {
+			delete(tracked.subscribers, ch)
+			close(ch)
+		}
+	}
+	return ch, unsubscribe, nil
+}
+
+// TrackReceipts keeps updating the status of the sent transactions until the
+// context is cancelled.
+func (tb *TxBuilder) TrackReceipts(ctx context.Context) {
+	ticker := time.NewTicker(receiptsPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			tb.updateReceipts(ctx)
+		}
+	}
+}
+
+func (tb *TxBuilder) track(hash common.Hash, nonce uint64) {
+	tb.mxTracked.Lock()
+	defer tb.mxTracked.Unlock()
+
+	if _, ok := tb.tracked[hash]; ok {
+		return
+	}
+	if len(tb.tracked) >= maxPendingTransactions {
+		// forget the one that was not updated for the longest time
+		var oldest common.Hash
+		var oldestAt time.Time
+		for h, t := range tb.tracked {
+			if oldestAt.IsZero() || t.updatedAt.Before(oldestAt) {
+				oldest, oldestAt = h, t.updatedAt
+			}
+		}
+		tb.forget(oldest)
+	}
+	tb.tracked[hash] = &trackedTransaction{
+		nonce:       nonce,
+		status:      Status{State: StateBroadcast, Hash: hash},
+		updatedAt:   time.Now(),
+		subscribers: make(map[chan Status]struct{}),
+	}
+}
+
+// forget stops tracking the transaction (expects the lock to be held).
+func (tb *TxBuilder) forget(hash common.Hash) {
+	if tracked, ok := tb.tracked[hash]; ok {
+		for ch := range tracked.subscribers {
+			close(ch)
+			// so that the unsubscribe does not close it again
+			delete(tracked.subscribers, ch)
+		}
+		delete(tb.tracked, hash)
+	}
+}
+
+func (tb *TxBuilder) updateReceipts(ctx context.Context) {
+	l := logutils.LoggerFromContext(ctx)
+
+	tb.mxTracked.Lock()
+	unresolved := make(map[common.Hash]uint64)
+	for hash, tracked := range tb.tracked {
+		switch {
+		case !tracked.status.Final():
+			unresolved[hash] = tracked.nonce
+		case time.Since(tracked.updatedAt) > receiptsRetention:
+			tb.forget(hash)
+		}
+	}
+	tb.mxTracked.Unlock()
+
+	if len(unresolved) == 0 {
+		return
+	}
+
+	head, err := tb.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		l.Warn("Failed to get the head to track the receipts", zap.Error(err))
+		return
+	}
+	// the nonce is read before the receipts, so that the transactions that
+	// are not found while their nonce is taken are surely replaced
+	nonce, err := tb.client.NonceAt(ctx, tb.address, head.Number)
+	if err != nil {
+		l.Warn("Failed to get the nonce to track the receipts", zap.Error(err))
+		return
+	}
+
+	for hash, txNonce := range unresolved {
+		status := Status{State: StateBroadcast, Hash: hash}
+
+		receipt, err := tb.client.TransactionReceipt(ctx, hash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			if txNonce < nonce {
+				status.State = StateReplaced
+			}
+		case err != nil:
+			l.Warn("Failed to get the transaction receipt",
+				zap.Error(err),
+				zap.String("tx_hash", hash.Hex()),
+			)
+			continue
+		default:
+			status.BlockNumber = receipt.BlockNumber.Uint64()
+			if head.Number.Uint64() >= status.BlockNumber {
+				status.Confirmations = head.Number.Uint64() - status.BlockNumber + 1
+			}
+			switch {
+			case receipt.Status == types.ReceiptStatusFailed:
+				status.State = StateFailed
+			case status.Confirmations >= max(tb.confirmations, 1):
+				status.State = StateConfirmed
+			default:
+				status.State = StateIncluded
+			}
+		}
+
+		tb.updateStatus(status)
+	}
+}
+
+// updateStatus notifies the subscribers of the changed status (the ones that
+// lag behind get only the latest one).
+func (tb *TxBuilder) updateStatus(status Status) {
+	tb.mxTracked.Lock()
+	defer tb.mxTracked.Unlock()
+
+	tracked, ok := tb.tracked[status.Hash]
+	if !ok || tracked.status == status {
+		return
+	}
+	tracked.status = status
+	tracked.updatedAt = time.Now()
+
+	for ch := range tracked.subscribers {
+		select {
+		case ch <- status:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- status
+		}
+		if status.Final() {
+			close(ch)
+		}
+	}
+	if status.Final() {
+		clear(tracked.subscribers)
+	}
+}
@@ -0,0 +1,105 @@
+package txbuilder
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clientStub struct {
+	client
+
+	head     uint64
+	nonce    uint64
+	receipts map[common.Hash]*types.Receipt
+}
+
+func (c *clientStub) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(c.head)}, nil
+}
+
+func (c *clientStub) NonceAt(context.Context, common.Address, *big.Int) (uint64, error) {
+	return c.nonce, nil
+}
+
+func (c *clientStub) TransactionReceipt(_ context.Context, hash common.Hash) (*types.Receipt, error) {
+	if receipt, ok := c.receipts[hash]; ok {
+		return receipt, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func TestUpdateReceipts(t *testing.T) {
+	ctx := context.Background()
+
+	included := common.HexToHash("0x01")
+	replaced := common.HexToHash("0x02")
+
+	stub := &clientStub{
+		head:  9,
+		nonce: 0,
+		receipts: map[common.Hash]*types.Receipt{
+			included: {BlockNumber: big.NewInt(10), Status: types.ReceiptStatusSuccessful},
+		},
+	}
+	tb := &TxBuilder{
+		client:        stub,
+		confirmations: 3,
+		tracked:       make(map[common.Hash]*trackedTransaction),
+	}
+	tb.track(included, 0)
+	tb.track(replaced, 1)
+
+	updates, unsubscribe, err := tb.Subscribe(ctx, included)
+	require.NoError(t, err)
+	defer unsubscribe()
+	assert.Equal(t, Status{State: StateBroadcast, Hash: included}, <-updates)
+
+	stub.head, stub.nonce = 10, 1
+	tb.updateReceipts(ctx)
+	assert.Equal(t, Status{State: StateIncluded, Hash: included, BlockNumber: 10, Confirmations: 1}, <-updates)
+
+	stub.head = 12
+	tb.updateReceipts(ctx)
+	assert.Equal(t, Status{State: StateConfirmed, Hash: included, BlockNumber: 10, Confirmations: 3}, <-updates)
+	_, open := <-updates
+	assert.False(t, open)
+
+	// its nonce is not taken yet
+	assert.Equal(t, StateBroadcast, tb.tracked[replaced].status.State)
+
+	stub.nonce = 2
+	tb.updateReceipts(ctx)
+	assert.Equal(t, StateReplaced, tb.tracked[replaced].status.State)
+}
+
+func TestTrackEviction(t *testing.T) {
+	ctx := context.Background()
+
+	tb := &TxBuilder{
+		client:  &clientStub{},
+		tracked: make(map[common.Hash]*trackedTransaction),
+	}
+	first := common.BigToHash(big.NewInt(0))
+	tb.track(first, 0)
+
+	updates, unsubscribe, err := tb.Subscribe(ctx, first)
+	require.NoError(t, err)
+	<-updates
+
+	// the oldest one (with the live subscriber) is evicted
+	for i := 1; i <= maxPendingTransactions; i++ {
+		tb.track(common.BigToHash(big.NewInt(int64(i))), uint64(i))
+	}
+	assert.NotContains(t, tb.tracked, first)
+	_, open := <-updates
+	assert.False(t, open)
+
+	assert.NotPanics(t, unsubscribe)
+}
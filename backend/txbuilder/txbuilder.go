@@ -1,22 +1,29 @@
 package txbuilder
 
 import (
+	"cmp"
 	"context"
 	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
-	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/flashbots/eth-faucet/backoff"
 	"github.com/flashbots/eth-faucet/config"
 	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/rpcutils"
+	"github.com/flashbots/eth-faucet/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -27,16 +34,47 @@ var (
 	ErrFailedToSendTransaction = errors.New("failed to send transaction")
 	ErrFailedToSignTransaction = errors.New("failed to sign transaction")
 	ErrFailedToSuggestGasPrice = errors.New("failed to suggest gas price")
+	ErrInsufficientFunds       = errors.New("insufficient funds on the faucet wallet")
 )
 
+const maxPendingTransactions = 1024
+
+type client interface {
+	bind.ContractTransactor
+
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	ChainID(ctx context.Context) (*big.Int, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
 type TxBuilder struct {
 	address       common.Address
 	backoffParams *backoff.Parameters
-	client        bind.ContractTransactor
+	client        client
+	confirmations uint64
 	privateKey    *ecdsa.PrivateKey
 	signer        types.Signer
 
 	nonce uint64
+
+	mxPending sync.Mutex
+	pending   map[common.Hash]*PendingTransaction
+
+	mxTracked sync.Mutex
+	tracked   map[common.Hash]*trackedTransaction
+}
+
+// PendingTransaction is a transaction that was sent, but was not yet seen
+// included on chain.
+type PendingTransaction struct {
+	Hash     common.Hash    `json:"hash"`
+	Nonce    uint64         `json:"nonce"`
+	To       common.Address `json:"to"`
+	Value    *big.Int       `json:"value"`
+	GasPrice *big.Int       `json:"gas_price"`
+	SentAt   time.Time      `json:"sent_at"`
 }
 
 func New(cfg *config.Config) (*TxBuilder, error) {
@@ -51,15 +89,7 @@ func New(cfg *config.Config) (*TxBuilder, error) {
 		BaseTimeout: cfg.RPC.Timeout,
 	}
 
-	l.Info("Connecting to rpc endpoint...", zap.String("rpc_endpoint", cfg.RPC.Endpoint))
-	var client *ethclient.Client
-	err = backoff.Backoff(context.Background(), backoffParams, func(_ context.Context) (_err error) {
-		client, _err = ethclient.Dial(cfg.RPC.Endpoint)
-		if _err != nil {
-			l.Warn("Failed to connect to rpc endpoint", zap.Error(_err))
-		}
-		return _err
-	})
+	client, err := rpcutils.Connect(&cfg.RPC, backoffParams)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %w", ErrFailedToConnectToRPC, err)
 	}
@@ -79,9 +109,12 @@ func New(cfg *config.Config) (*TxBuilder, error) {
 	tb := &TxBuilder{
 		address:       crypto.PubkeyToAddress(privateKey.PublicKey),
 		backoffParams: backoffParams,
-		client:        client,
+		client:        instrumentedClient{client},
+		confirmations: cfg.Chain.Confirmations,
+		pending:       make(map[common.Hash]*PendingTransaction),
 		privateKey:    privateKey,
 		signer:        types.NewEIP155Signer(chainID),
+		tracked:       make(map[common.Hash]*trackedTransaction),
 	}
 	tb.refreshNonce(logutils.ContextWithLogger(context.Background(), zap.L()))
 
@@ -92,13 +125,53 @@ func (tb *TxBuilder) Address() string {
 	return tb.address.String()
 }
 
-func (tb *TxBuilder) SendFunds(ctx context.Context, to string, amount *big.Int) (common.Hash, error) {
+// Nonce returns the nonce the next transaction will be sent with.
+func (tb *TxBuilder) Nonce() uint64 {
+	return atomic.LoadUint64(&tb.nonce)
+}
+
+// Balance returns the balance of the wallet.
+func (tb *TxBuilder) Balance(ctx context.Context) (*big.Int, error) {
+	return tb.client.BalanceAt(ctx, tb.address, nil)
+}
+
+// ChainID returns the chain id the transactions are signed for (the one the
+// rpc reported on start).
+func (tb *TxBuilder) ChainID() *big.Int {
+	return tb.signer.ChainID()
+}
+
+// RPCChainID returns the chain id the rpc reports now.
+func (tb *TxBuilder) RPCChainID(ctx context.Context) (*big.Int, error) {
+	return tb.client.ChainID(ctx)
+}
+
+// Head returns the latest block header the rpc knows of.
+func (tb *TxBuilder) Head(ctx context.Context) (*types.Header, error) {
+	return tb.client.HeaderByNumber(ctx, nil)
+}
+
+// PendingNonce returns the nonce of the wallet that the rpc expects next
+// (unlike Nonce, which is the one tracked locally).
+func (tb *TxBuilder) PendingNonce(ctx context.Context) (uint64, error) {
+	return tb.client.PendingNonceAt(ctx, tb.address)
+}
+
+// SendFunds sends the amount to the address.  The returned transaction (if
+// any) tells the hash and the nonce, even when sending has failed.
+func (tb *TxBuilder) SendFunds(ctx context.Context, to string, amount *big.Int) (_ *types.Transaction, err error) {
 	l := logutils.LoggerFromContext(ctx)
 
+	ctx, span := tracing.Start(ctx, "txbuilder.SendFunds", trace.WithAttributes(
+		attribute.String("tx.to", to),
+		attribute.String("tx.value", amount.String()),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	gasLimit := uint64(21000)
 
 	var gasPrice *big.Int
-	err := backoff.Backoff(ctx, tb.backoffParams, func(ctx context.Context) (_err error) {
+	err = backoff.Backoff(ctx, tb.backoffParams, func(ctx context.Context) (_err error) {
 		gasPrice, _err = tb.client.SuggestGasPrice(ctx)
 		if _err != nil {
 			l.Warn("Failed to get suggested gas price", zap.Error(_err))
@@ -106,7 +179,7 @@ func (tb *TxBuilder) SendFunds(ctx context.Context, to string, amount *big.Int)
 		return
 	})
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("%w: %w", ErrFailedToSuggestGasPrice, err)
+		return nil, fmt.Errorf("%w: %w", ErrFailedToSuggestGasPrice, err)
 	}
 
 	address := common.HexToAddress(to)
@@ -118,15 +191,21 @@ func (tb *TxBuilder) SendFunds(ctx context.Context, to string, amount *big.Int)
 		Value:    amount,
 	})
 
+	span.SetAttributes(attribute.Int64("tx.nonce", int64(unsignedTx.Nonce())))
+
 	signedTx, err := types.SignTx(unsignedTx, tb.signer, tb.privateKey)
 	if err != nil {
-		return signedTx.Hash(), fmt.Errorf("%w: %w", ErrFailedToSignTransaction, err)
+		return unsignedTx, fmt.Errorf("%w: %w", ErrFailedToSignTransaction, err)
 	}
+	span.SetAttributes(attribute.String("tx.hash", signedTx.Hash().Hex()))
 
 	err = backoff.Backoff(ctx, tb.backoffParams, func(_ctx context.Context) (_err error) {
 		_err = tb.client.SendTransaction(_ctx, signedTx)
 		if _err != nil {
 			l.Warn("Failed to send transaction", zap.Error(_err), zap.String("tx_hash", signedTx.Hash().Hex()))
+			if strings.Contains(_err.Error(), "insufficient funds") {
+				return fmt.Errorf("%w: %w", ErrInsufficientFunds, _err)
+			}
 			if strings.Contains(_err.Error(), "nonce") {
 				if _errRefresh := tb.refreshNonce(ctx); _errRefresh != nil {
 					return fmt.Errorf("%w: %w", _err, _errRefresh)
@@ -137,10 +216,78 @@ func (tb *TxBuilder) SendFunds(ctx context.Context, to string, amount *big.Int)
 		return
 	})
 	if err != nil {
-		return signedTx.Hash(), fmt.Errorf("%w: %w", ErrFailedToSendTransaction, err)
+		return signedTx, fmt.Errorf("%w: %w", ErrFailedToSendTransaction, err)
 	}
 
-	return signedTx.Hash(), nil
+	tb.trackPending(&PendingTransaction{
+		Hash:     signedTx.Hash(),
+		Nonce:    signedTx.Nonce(),
+		To:       address,
+		Value:    amount,
+		GasPrice: gasPrice,
+		SentAt:   time.Now().UTC(),
+	})
+	tb.track(signedTx.Hash(), signedTx.Nonce())
+
+	return signedTx, nil
+}
+
+// Pending returns the sent transactions that are not yet included on chain
+// (ordered by nonce).
+func (tb *TxBuilder) Pending(ctx context.Context) ([]*PendingTransaction, error) {
+	var included uint64
+	err := backoff.Backoff(ctx, tb.backoffParams, func(ctx context.Context) (_err error) {
+		included, _err = tb.client.NonceAt(ctx, tb.address, nil)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tb.mxPending.Lock()
+	defer tb.mxPending.Unlock()
+
+	res := make([]*PendingTransaction, 0, len(tb.pending))
+	for hash, tx := range tb.pending {
+		if tx.Nonce < included {
+			delete(tb.pending, hash)
+			continue
+		}
+		res = append(res, tx)
+	}
+	slices.SortFunc(res, func(a, b *PendingTransaction) int {
+		return cmp.Compare(a.Nonce, b.Nonce)
+	})
+
+	return res, nil
+}
+
+// ResyncNonce re-reads the pending nonce of the wallet from the rpc (e.g. after
+// the transactions were dropped from the mempool).  It returns the nonces
+// before and after the resync.
+func (tb *TxBuilder) ResyncNonce(ctx context.Context) (uint64, uint64, error) {
+	before := atomic.LoadUint64(&tb.nonce)
+	if err := tb.refreshNonce(ctx); err != nil {
+		return before, before, err
+	}
+	return before, atomic.LoadUint64(&tb.nonce), nil
+}
+
+func (tb *TxBuilder) trackPending(tx *PendingTransaction) {
+	tb.mxPending.Lock()
+	defer tb.mxPending.Unlock()
+
+	if len(tb.pending) >= maxPendingTransactions {
+		// forget the oldest one
+		var oldest *PendingTransaction
+		for _, p := range tb.pending {
+			if oldest == nil || p.Nonce < oldest.Nonce {
+				oldest = p
+			}
+		}
+		delete(tb.pending, oldest.Hash)
+	}
+	tb.pending[tx.Hash] = tx
 }
 
 func (tb *TxBuilder) refreshNonce(ctx context.Context) error {
@@ -158,7 +305,7 @@ func (tb *TxBuilder) refreshNonce(ctx context.Context) error {
 		return fmt.Errorf("%w: %w", ErrFailedToRefreshNonce, err)
 	}
 
-	tb.nonce = nonce
+	atomic.StoreUint64(&tb.nonce, nonce)
 	return nil
 }
 
@@ -0,0 +1,129 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrAmountInvalid       = errors.New("invalid amount")
+	ErrAmountNegative      = errors.New("amount must not be negative")
+	ErrAmountTooPrecise    = errors.New("amount is more precise than one wei")
+	ErrAmountUnitUnknown   = errors.New("unknown amount unit")
+	ErrDecimalsUnsupported = errors.New("unsupported token decimals")
+)
+
+const maxDecimals = 77 // 10^77 is still within uint256
+
+// The amounts are bounded before they are parsed, as the cost of parsing
+// grows with the exponent (e.g. "1e1000000").
+const (
+	maxAmountLength = 100
+	maxExponent     = 2 * maxDecimals
+)
+
+// exponents are the powers of ten of the units relative to one wei.  Whole
+// tokens (no unit) are 10^decimals wei instead.
+var exponents = map[string]int64{
+	"wei":   0,
+	"gwei":  9,
+	"ether": 18,
+	"eth":   18,
+}
+
+// Parse converts the amount into wei.  The amount is either a decimal number
+// of whole tokens (e.g. "0.05" or "1e-2") or a decimal number followed by a
+// unit (e.g. "50000000 gwei" or "1e17 wei").
+//
+// The conversion is exact: the amounts that do not amount to a whole number of
+// wei are rejected.
+func Parse(amount string, decimals uint) (*big.Int, error) {
+	if decimals > maxDecimals {
+		return nil, fmt.Errorf("%w: %d", ErrDecimalsUnsupported, decimals)
+	}
+
+	number, unit, _ := strings.Cut(strings.TrimSpace(amount), " ")
+	exponent := int64(decimals)
+	if unit = strings.ToLower(strings.TrimSpace(unit)); unit != "" {
+		e, known := exponents[unit]
+		if !known {
+			return nil, fmt.Errorf("%w: %s", ErrAmountUnitUnknown, unit)
+		}
+		exponent = e
+	}
+
+	if !isDecimal(number) {
+		return nil, fmt.Errorf("%w: %s", ErrAmountInvalid, amount)
+	}
+	value, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrAmountInvalid, amount)
+	}
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAmountNegative, amount)
+	}
+
+	value.Mul(value, new(big.Rat).SetInt(pow10(exponent)))
+	if !value.IsInt() {
+		return nil, fmt.Errorf("%w: %s", ErrAmountTooPrecise, amount)
+	}
+
+	return new(big.Int).Set(value.Num()), nil
+}
+
+// Validate checks the amount without knowing the token's decimals (the
+// precision of the whole-token amounts can't be checked then).
+func Validate(amount string) error {
+	_, err := Parse(amount, maxDecimals)
+	return err
+}
+
+// Format renders the amount of wei as a decimal number of whole tokens
+// without the trailing zeros (e.g. "0.05").
+func Format(wei *big.Int, decimals uint) string {
+	if decimals > maxDecimals {
+		return wei.String()
+	}
+	whole, frac := new(big.Int).QuoRem(wei, pow10(int64(decimals)), new(big.Int))
+	if frac.Sign() == 0 {
+		return whole.String()
+	}
+
+	fracStr := fmt.Sprintf("%0*s", decimals, new(big.Int).Abs(frac).String())
+	res := whole.String() + "." + strings.TrimRight(fracStr, "0")
+	if wei.Sign() < 0 && whole.Sign() == 0 {
+		res = "-" + res
+	}
+	return res
+}
+
+// isDecimal checks that the number is a plain decimal one (the fractions, the
+// other bases and the binary exponents big.Rat accepts are not) that is short
+// and has a small exponent.
+func isDecimal(number string) bool {
+	if number == "" || len(number) > maxAmountLength {
+		return false
+	}
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(number), "e")
+	if hasExponent {
+		e, err := strconv.ParseInt(exponent, 10, 64)
+		if err != nil || e < -maxExponent || e > maxExponent {
+			return false
+		}
+	}
+	if mantissa != "" && (mantissa[0] == '+' || mantissa[0] == '-') {
+		mantissa = mantissa[1:]
+	}
+	whole, frac, _ := strings.Cut(mantissa, ".")
+	if whole == "" && frac == "" {
+		return false
+	}
+	return strings.Trim(whole+frac, "0123456789") == ""
+}
+
+func pow10(exponent int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(exponent), nil)
+}
@@ -0,0 +1,48 @@
+package units_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/flashbots/eth-faucet/units"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for amount, wei := range map[string]string{
+		"1":             "1000000000000000000",
+		"0.05":          "50000000000000000",
+		"50000000 gwei": "50000000000000000",
+		"1e17 wei":      "100000000000000000",
+		"1.5 ether":     "1500000000000000000",
+		"2.5e-2":        "25000000000000000",
+	} {
+		res, err := units.Parse(amount, 18)
+		require.NoError(t, err, amount)
+		assert.Equal(t, wei, res.String(), amount)
+	}
+
+	res, err := units.Parse("0.05", 6)
+	require.NoError(t, err)
+	assert.Equal(t, "50000", res.String())
+
+	_, err = units.Parse("0.5 wei", 18)
+	assert.ErrorIs(t, err, units.ErrAmountTooPrecise)
+	_, err = units.Parse("-1", 18)
+	assert.ErrorIs(t, err, units.ErrAmountNegative)
+	_, err = units.Parse("1 finney", 18)
+	assert.ErrorIs(t, err, units.ErrAmountUnitUnknown)
+	for _, amount := range []string{"one", "1e1000000", "1e-1000000", "0x1p10", "1/2", ".", "1e", "--1", strings.Repeat("1", 101)} {
+		_, err = units.Parse(amount, 18)
+		assert.ErrorIs(t, err, units.ErrAmountInvalid, amount)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "0.05", units.Format(big.NewInt(50000000000000000), 18))
+	assert.Equal(t, "10", units.Format(big.NewInt(10000000), 6))
+	assert.Equal(t, "0.000000000000000001", units.Format(big.NewInt(1), 18))
+	assert.Equal(t, "12", units.Format(big.NewInt(12), 0))
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/units"
+	"go.uber.org/zap"
+)
+
+// balanceCheckInterval is how often the wallet's balance is checked
+const balanceCheckInterval = time.Minute
+
+// Wallet is the faucet's wallet.
+type Wallet interface {
+	Address() string
+	Balance(ctx context.Context) (*big.Int, error)
+}
+
+// WatchBalance sends the wallet.low_balance event when the wallet's balance
+// drops below the threshold, until the context is cancelled.  The event is
+// sent once per drop (by one of the replicas), and then again only after the
+// wallet has been topped up.
+func (d *Dispatcher) WatchBalance(ctx context.Context, wallet Wallet) {
+	ticker := time.NewTicker(balanceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		d.checkBalance(ctx, wallet)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) checkBalance(ctx context.Context, wallet Wallet) {
+	l := logutils.LoggerFromContext(ctx)
+
+	balance, err := wallet.Balance(ctx)
+	if err != nil {
+		l.Warn("Failed to get wallet balance for webhooks", zap.Error(err))
+		return
+	}
+
+	var first bool
+	err = backoff.Backoff(ctx, d.redisBackoffParams, func(ctx context.Context) (_err error) {
+		if balance.Cmp(d.lowBalance) >= 0 {
+			return d.redis.Del(ctx, d.keyLowBalance()).Err()
+		}
+		first, _err = d.redis.SetNX(ctx, d.keyLowBalance(), balance.String(), 0).Result()
+		return
+	})
+	if err != nil {
+		l.Warn("Failed to track low wallet balance", zap.Error(err))
+		return
+	}
+	if !first {
+		return
+	}
+
+	l.Warn("Wallet balance is low",
+		zap.String("balance_wei", balance.String()),
+		zap.String("threshold_wei", d.lowBalance.String()),
+	)
+	d.Send(ctx, EventWalletLowBalance, &Balance{
+		Address:      wallet.Address(),
+		Balance:      units.Format(balance, d.decimals),
+		BalanceWei:   balance.String(),
+		Threshold:    units.Format(d.lowBalance, d.decimals),
+		ThresholdWei: d.lowBalance.String(),
+	})
+}
+
+func (d *Dispatcher) keyLowBalance() string {
+	return d.prefix + "webhooks:low_balance"
+}
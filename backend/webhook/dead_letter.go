@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+)
+
+// deadLetterSize is how many of the latest dead letters are kept
+const deadLetterSize = 1000
+
+// DeadLetter is the delivery that failed after all of the retries.
+type DeadLetter struct {
+	URL      string    `json:"url"`
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetters returns the latest dead letters (the newest first).
+func (d *Dispatcher) DeadLetters(ctx context.Context) ([]DeadLetter, error) {
+	var items []string
+	err := backoff.Backoff(ctx, d.redisBackoffParams, func(ctx context.Context) (_err error) {
+		items, _err = d.redis.LRange(ctx, d.keyDeadLetters(), 0, -1).Result()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]DeadLetter, 0, len(items))
+	for _, item := range items {
+		var deadLetter DeadLetter
+		if err := json.Unmarshal([]byte(item), &deadLetter); err != nil {
+			return nil, err
+		}
+		res = append(res, deadLetter)
+	}
+	return res, nil
+}
+
+func (d *Dispatcher) recordDeadLetter(ctx context.Context, deadLetter DeadLetter) error {
+	item, err := json.Marshal(deadLetter)
+	if err != nil {
+		return err
+	}
+	return backoff.Backoff(ctx, d.redisBackoffParams, func(ctx context.Context) error {
+		pipe := d.redis.TxPipeline()
+		pipe.LPush(ctx, d.keyDeadLetters(), item)
+		pipe.LTrim(ctx, d.keyDeadLetters(), 0, deadLetterSize-1)
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+}
+
+func (d *Dispatcher) keyDeadLetters() string {
+	return d.prefix + "webhooks:dead_letters"
+}
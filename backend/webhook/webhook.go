@@ -0,0 +1,287 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/flashbots/eth-faucet/backoff"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/flashbots/eth-faucet/logutils"
+	"github.com/flashbots/eth-faucet/metrics"
+	"github.com/flashbots/eth-faucet/redisutils"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// The events the webhooks are sent on.
+const (
+	EventFundConfirmed    = "fund.confirmed"     // the payout is confirmed on chain
+	EventFundFailed       = "fund.failed"        // the payout failed to be sent, reverted or got replaced
+	EventFundRequested    = "fund.requested"     // the request passed the checks, the payout is about to be sent
+	EventFundSent         = "fund.sent"          // the payout is broadcast
+	EventWalletLowBalance = "wallet.low_balance" // the wallet's balance dropped below the threshold
+)
+
+const (
+	HeaderDelivery  = "X-Faucet-Delivery"
+	HeaderEvent     = "X-Faucet-Event"
+	HeaderSignature = "X-Faucet-Signature"
+)
+
+const (
+	// queueSize is how many deliveries can wait for the workers
+	queueSize = 1024
+
+	// workers is how many deliveries are made concurrently
+	workers = 4
+)
+
+var (
+	ErrDeliveryFailed = errors.New("webhook delivery failed")
+	ErrQueueFull      = errors.New("webhook queue is full")
+	ErrShutdown       = errors.New("server shut down before the delivery")
+)
+
+// Event is the body of the webhook request.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// Fund is the data of the fund.* events.
+type Fund struct {
+	Address     string `json:"address"`
+	Amount      string `json:"amount,omitempty"`
+	AmountWei   string `json:"amount_wei,omitempty"`
+	APIKeyID    string `json:"api_key_id,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Provider    string `json:"identity_provider"`
+	RequestID   string `json:"request_id,omitempty"`
+	TxHash      string `json:"tx_hash,omitempty"`
+	Username    string `json:"identity_username"`
+}
+
+// Balance is the data of the wallet.low_balance event.
+type Balance struct {
+	Address      string `json:"address"`
+	Balance      string `json:"balance"`
+	BalanceWei   string `json:"balance_wei"`
+	Threshold    string `json:"threshold"`
+	ThresholdWei string `json:"threshold_wei"`
+}
+
+type delivery struct {
+	body         []byte
+	event        Event
+	subscription config.Webhook
+}
+
+// Dispatcher delivers the events to the subscribed webhooks in the
+// background.  The deliveries that fail after the retries are kept in redis
+// as the dead letters.
+type Dispatcher struct {
+	backoffParams      *backoff.Parameters
+	client             *http.Client
+	decimals           uint
+	lowBalance         *big.Int
+	prefix             string
+	queue              chan delivery
+	redis              *redis.Client
+	redisBackoffParams *backoff.Parameters
+	subscriptions      []config.Webhook
+}
+
+// New returns the dispatcher, or nil if there are no subscriptions.
+func New(cfg *config.Config, _redis *redis.Client) (*Dispatcher, error) {
+	if !cfg.Webhooks.Enabled() {
+		return nil, nil
+	}
+
+	lowBalance, err := cfg.Webhooks.LowBalanceWei(cfg.Health, cfg.Faucet, cfg.Chain.TokenDecimals)
+	if err != nil {
+		return nil, err
+	}
+
+	redisBackoffParams := &backoff.Parameters{
+		BaseTimeout: cfg.Redis.Timeout,
+	}
+
+	return &Dispatcher{
+		backoffParams: &backoff.Parameters{
+			BaseTimeout:    cfg.Webhooks.Timeout,
+			Multiplier:     2,
+			MaximumTimeout: time.Minute,
+			TotalTimeout:   cfg.Webhooks.RetryTimeout,
+		},
+		client:             &http.Client{},
+		decimals:           cfg.Chain.TokenDecimals,
+		lowBalance:         lowBalance,
+		prefix:             redisutils.Prefix(&cfg.Redis),
+		queue:              make(chan delivery, queueSize),
+		redis:              _redis,
+		redisBackoffParams: redisBackoffParams,
+		subscriptions:      cfg.Webhooks.Subscriptions,
+	}, nil
+}
+
+// Send queues the event for the delivery to the webhooks subscribed to it.
+func (d *Dispatcher) Send(ctx context.Context, eventType string, data any) {
+	l := logutils.LoggerFromContext(ctx)
+
+	event := Event{
+		ID:        uuid.Must(uuid.NewRandom()).String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		l.Error("Failed to marshal webhook event", zap.Error(err), zap.String("event", eventType))
+		return
+	}
+
+	for _, subscription := range d.subscriptions {
+		if !subscription.Wants(eventType) {
+			continue
+		}
+		delivery := delivery{body: body, event: event, subscription: subscription}
+		select {
+		case d.queue <- delivery:
+		default:
+			d.fail(ctx, delivery, ErrQueueFull)
+		}
+	}
+}
+
+// Run delivers the queued events until the context is cancelled.  The ones
+// that are still queued by then are recorded as the dead letters.  It returns
+// only once the deliveries in progress are done with too.
+func (d *Dispatcher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case delivery := <-d.queue:
+					d.deliver(ctx, delivery)
+				}
+			}
+		}()
+	}
+	<-ctx.Done()
+	wg.Wait()
+
+	ctx = context.WithoutCancel(ctx)
+	for {
+		select {
+		case delivery := <-d.queue:
+			d.fail(ctx, delivery, ErrShutdown)
+		default:
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery delivery) {
+	l := logutils.LoggerFromContext(ctx)
+
+	err := backoff.Backoff(ctx, d.backoffParams, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.subscription.URL, bytes.NewReader(delivery.body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "eth-faucet")
+		req.Header.Set(HeaderDelivery, delivery.event.ID)
+		req.Header.Set(HeaderEvent, delivery.event.Type)
+		// signed on every attempt, so that the receivers could reject the
+		// stale (replayed) requests
+		req.Header.Set(HeaderSignature, Sign(delivery.subscription.Secret, time.Now(), delivery.body))
+
+		res, err := d.client.Do(req)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return err
+			}
+			return backoff.Retryable(err)
+		}
+		_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+		res.Body.Close()
+
+		switch {
+		case res.StatusCode >= 200 && res.StatusCode < 300:
+			return nil
+		case res.StatusCode == http.StatusRequestTimeout,
+			res.StatusCode == http.StatusTooManyRequests,
+			res.StatusCode >= 500:
+			return backoff.RetryableErrorf("%w: %s", ErrDeliveryFailed, res.Status)
+		default:
+			return fmt.Errorf("%w: %s", ErrDeliveryFailed, res.Status)
+		}
+	})
+	if err != nil {
+		d.fail(context.WithoutCancel(ctx), delivery, err)
+		return
+	}
+
+	metrics.RecordWebhookDelivery(delivery.event.Type, "delivered")
+	l.Debug("Delivered webhook",
+		zap.String("event", delivery.event.Type),
+		zap.String("event_id", delivery.event.ID),
+		zap.String("url", delivery.subscription.URL),
+	)
+}
+
+func (d *Dispatcher) fail(ctx context.Context, delivery delivery, err error) {
+	l := logutils.LoggerFromContext(ctx)
+
+	metrics.RecordWebhookDelivery(delivery.event.Type, "failed")
+	l.Error("Failed to deliver webhook",
+		zap.Error(err),
+		zap.String("event", delivery.event.Type),
+		zap.String("event_id", delivery.event.ID),
+		zap.String("url", delivery.subscription.URL),
+	)
+
+	if err := d.recordDeadLetter(ctx, DeadLetter{
+		URL:      delivery.subscription.URL,
+		Event:    delivery.event,
+		Error:    err.Error(),
+		FailedAt: time.Now().UTC(),
+	}); err != nil {
+		l.Error("Failed to record webhook dead letter",
+			zap.Error(err),
+			zap.String("event_id", delivery.event.ID),
+		)
+	}
+}
+
+// Sign returns the value of the signature header: the timestamp together
+// with the hex of hmac-sha256 of `<timestamp>.<body>` keyed by the secret.
+func Sign(secret string, at time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flashbots/eth-faucet/config"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher(t *testing.T) {
+	type request struct {
+		body   []byte
+		header http.Header
+	}
+	var attempts atomic.Int32
+	delivered := make(chan request, 2)
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		delivered <- request{body: body, header: r.Header}
+	}))
+	defer flaky.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer broken.Close()
+
+	cfg := &config.Config{
+		Chain:  config.Chain{TokenDecimals: 18},
+		Faucet: config.Faucet{Payout: "1"},
+		Redis:  config.Redis{Timeout: time.Second},
+		Webhooks: config.Webhooks{
+			RetryTimeout: 5 * time.Second,
+			Subscriptions: []config.Webhook{
+				{Secret: "secret", URL: flaky.URL},
+				{Events: []string{EventFundFailed}, Secret: "secret", URL: broken.URL},
+			},
+			Timeout: 100 * time.Millisecond,
+		},
+	}
+	d, err := New(cfg, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	d.Send(ctx, EventFundSent, &Fund{Address: "0xa1", TxHash: "0x01"})
+	d.Send(ctx, EventFundFailed, &Fund{Address: "0xa2", Error: "boom"})
+
+	// both events reach the first subscription (one of them on the retry)
+	events := make([]string, 0, 2)
+	for range 2 {
+		r := <-delivered
+		events = append(events, r.header.Get(HeaderEvent))
+		timestamp, _, _ := strings.Cut(strings.TrimPrefix(r.header.Get(HeaderSignature), "t="), ",")
+		unix, err := strconv.ParseInt(timestamp, 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, Sign("secret", time.Unix(unix, 0), r.body), r.header.Get(HeaderSignature))
+	}
+	assert.ElementsMatch(t, []string{EventFundSent, EventFundFailed}, events)
+	assert.Equal(t, int32(3), attempts.Load())
+
+	// the second subscription rejects the failed event for good
+	assert.Eventually(t, func() bool {
+		deadLetters, err := d.DeadLetters(ctx)
+		require.NoError(t, err)
+		return len(deadLetters) == 1 &&
+			deadLetters[0].URL == broken.URL &&
+			deadLetters[0].Event.Type == EventFundFailed
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestDispatcherShutdown(t *testing.T) {
+	received, release := make(chan struct{}), make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		close(received)
+		<-release
+	}))
+	defer hanging.Close()
+	defer close(release)
+
+	cfg := &config.Config{
+		Chain:  config.Chain{TokenDecimals: 18},
+		Faucet: config.Faucet{Payout: "1"},
+		Redis:  config.Redis{Timeout: time.Second},
+		Webhooks: config.Webhooks{
+			RetryTimeout:  5 * time.Second,
+			Subscriptions: []config.Webhook{{Secret: "secret", URL: hanging.URL}},
+			Timeout:       5 * time.Second,
+		},
+	}
+	d, err := New(cfg, redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()}))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		d.Run(ctx)
+	}()
+
+	d.Send(ctx, EventFundSent, &Fund{Address: "0xa1", TxHash: "0x01"})
+	<-received
+	cancel()
+	<-done
+
+	// the delivery in progress is accounted for by the time run returns
+	deadLetters, err := d.DeadLetters(context.Background())
+	require.NoError(t, err)
+	require.Len(t, deadLetters, 1)
+	assert.Equal(t, EventFundSent, deadLetters[0].Event.Type)
+}